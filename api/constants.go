@@ -6,4 +6,8 @@ const (
 	Room       = baseUrl + "/room"
 	RoomCreate = Room + "/create"
 	RoomExists = Room + "/exists"
+	Rooms      = baseUrl + "/rooms"
+	Schema     = baseUrl + "/schema/events"
+	WordPacks  = baseUrl + "/words/packs"
+	Metrics    = "/debug/vars"
 )
@@ -0,0 +1,162 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers, so that the game loop can be driven faster than real time
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-use timer created by a Clock
+type Timer interface {
+	// C returns the channel on which the time is delivered once the timer fires
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, returning false if it has already fired or been stopped
+	Stop() bool
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+type realClock struct{}
+
+// Real returns a Clock backed by the system's clock
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+type scaledClock struct {
+	start time.Time
+	scale float64
+}
+
+// NewScaled returns a Clock which runs scale times faster than the system's clock, starting from the current time.
+// A scale of 1 is equivalent to the Real clock.
+func NewScaled(scale float64) Clock {
+	if scale == 1 {
+		return Real()
+	}
+	return scaledClock{
+		start: time.Now(),
+		scale: scale,
+	}
+}
+
+func (c scaledClock) Now() time.Time {
+	elapsed := time.Since(c.start)
+	return c.start.Add(time.Duration(float64(elapsed) * c.scale))
+}
+
+func (c scaledClock) NewTimer(d time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(time.Duration(float64(d) / c.scale))}
+}
+
+// Manual is a Clock which only moves when it is advanced, so that timing can be tested deterministically. It is safe
+// for concurrent use.
+type Manual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// NewManual returns a Manual clock which is stopped at the given time
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+func (c *Manual) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Manual) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &manualTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward, firing the timers which are due in order of their deadlines
+func (c *Manual) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- t.deadline
+	}
+	c.timers = pending
+}
+
+// Timers returns the number of timers which have not fired or been stopped
+func (c *Manual) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+type manualTimer struct {
+	clock    *Manual
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
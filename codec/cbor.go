@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/kvnxiao/pictorio/events"
+	"github.com/rs/zerolog/log"
+)
+
+// cborCodec sends events as CBOR (RFC 8949) binary messages. Events are encoded with the same shape as their JSON
+// encoding, i.e. as maps keyed by the fields' JSON names, except that byte slices are sent as byte strings and floats
+// in their shortest lossless form.
+type cborCodec struct {
+	legacy bool
+}
+
+var (
+	cborEncoding = mustEncMode(cbor.EncOptions{ShortestFloat: cbor.ShortestFloat16})
+	// Messages read from connections must not repeat a map key, which JSON decoders would silently overwrite
+	cborDecoding = mustDecMode(cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF})
+)
+
+func mustEncMode(options cbor.EncOptions) cbor.EncMode {
+	mode, err := options.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+func mustDecMode(options cbor.DecOptions) cbor.DecMode {
+	mode, err := options.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+// cborEnvelope is the GameEvent envelope of events sent to a connection
+type cborEnvelope struct {
+	Type interface{}              `cbor:"type"`
+	Data events.SerializableEvent `cbor:"data"`
+	Seq  uint64                   `cbor:"seq,omitempty"`
+}
+
+// cborIncomingEnvelope is the GameEvent envelope of messages read from a connection, which keeps the payload encoded
+// until its type is known
+type cborIncomingEnvelope struct {
+	Type events.GameEventType `cbor:"type"`
+	Data cbor.RawMessage      `cbor:"data"`
+	ID   string               `cbor:"id"`
+}
+
+func (cborCodec) Subprotocol() string {
+	return SubprotocolCBOR
+}
+
+func (cborCodec) Binary() bool {
+	return true
+}
+
+func (c cborCodec) Encode(event events.SerializableEvent, seq uint64) []byte {
+	envelope := cborEnvelope{
+		Type: event.GameEventType().Name(),
+		Data: event,
+		Seq:  seq,
+	}
+	if c.legacy {
+		envelope.Type = int(event.GameEventType())
+	}
+
+	bytes, err := cborEncoding.Marshal(envelope)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not marshal " + event.GameEventType().String() + " into CBOR")
+		return nil
+	}
+	return bytes
+}
+
+func (cborCodec) Decode(msg []byte) (events.GameEvent, error) {
+	var envelope cborIncomingEnvelope
+	if err := cborDecoding.Unmarshal(msg, &envelope); err != nil {
+		return events.GameEvent{}, err
+	}
+	return events.GameEvent{
+		Type: envelope.Type,
+		Data: []byte(envelope.Data),
+		ID:   envelope.ID,
+	}, nil
+}
+
+func (cborCodec) Unmarshal(data []byte, v interface{}) error {
+	return cborDecoding.Unmarshal(data, v)
+}
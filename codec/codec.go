@@ -0,0 +1,85 @@
+package codec
+
+import (
+	"github.com/kvnxiao/pictorio/events"
+)
+
+// WebSocket subprotocols which select the codec of a connection. Connections which do not negotiate a subprotocol
+// use JSON.
+const (
+	SubprotocolJSON = "pictorio.json"
+	SubprotocolCBOR = "pictorio.cbor"
+)
+
+// Codec encodes events sent to a connection, and decodes messages read from a connection
+type Codec interface {
+	// Subprotocol is the WebSocket subprotocol which selects the codec
+	Subprotocol() string
+	// Binary reports whether the codec's messages are sent as binary instead of text WebSocket messages
+	Binary() bool
+	// Encode serializes an event into a GameEvent envelope, numbered with the given sequence number
+	Encode(event events.SerializableEvent, seq uint64) []byte
+	// Decode decodes the GameEvent envelope of a message read from a connection. The event's payload is left in the
+	// codec's encoding, to be decoded with Unmarshal once the event's type is known.
+	Decode(msg []byte) (events.GameEvent, error)
+	// Unmarshal decodes the payload of an event read from a connection into v
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	JSON Codec = jsonCodec{}
+	CBOR Codec = cborCodec{}
+)
+
+// Codecs of the legacy protocol, which send event types by their numbers
+var (
+	LegacyJSON Codec = jsonCodec{legacy: true}
+	LegacyCBOR Codec = cborCodec{legacy: true}
+)
+
+// Subprotocols returns the supported subprotocols in order of preference, which are offered when accepting a
+// WebSocket connection
+func Subprotocols() []string {
+	return []string{SubprotocolCBOR, SubprotocolJSON}
+}
+
+// ForConnection returns the codec selected by a connection's negotiated subprotocol and protocol version, defaulting
+// to JSON
+func ForConnection(subprotocol string, handshake events.Handshake) Codec {
+	legacy := handshake.LegacyEventTypes()
+	switch {
+	case subprotocol == SubprotocolCBOR && legacy:
+		return LegacyCBOR
+	case subprotocol == SubprotocolCBOR:
+		return CBOR
+	case legacy:
+		return LegacyJSON
+	default:
+		return JSON
+	}
+}
+
+// Encoded encodes an event at most once per codec, for events which are sent to many connections
+type Encoded struct {
+	event   events.SerializableEvent
+	seq     uint64
+	encoded map[Codec][]byte
+}
+
+func NewEncoded(event events.SerializableEvent, seq uint64) *Encoded {
+	return &Encoded{
+		event:   event,
+		seq:     seq,
+		encoded: make(map[Codec][]byte, 2),
+	}
+}
+
+// Bytes returns the event encoded with the given codec
+func (e *Encoded) Bytes(codec Codec) []byte {
+	if encoded, ok := e.encoded[codec]; ok {
+		return encoded
+	}
+	encoded := codec.Encode(e.event, e.seq)
+	e.encoded[codec] = encoded
+	return encoded
+}
@@ -0,0 +1,141 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/model"
+)
+
+var codecs = map[string]Codec{
+	"json":        JSON,
+	"cbor":        CBOR,
+	"legacy json": LegacyJSON,
+	"legacy cbor": LegacyCBOR,
+}
+
+// roundTrip encodes an event with a codec, and decodes it back into a value of the event's type
+func roundTrip(t *testing.T, c Codec, event events.SerializableEvent) events.SerializableEvent {
+	t.Helper()
+
+	encoded := c.Encode(event, 42)
+	if encoded == nil {
+		t.Fatalf("could not encode %s", event.GameEventType())
+	}
+	decoded, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatalf("could not decode %s: %v", event.GameEventType(), err)
+	}
+	if decoded.Type != event.GameEventType() {
+		t.Fatalf("decoded event type %s, expected %s", decoded.Type, event.GameEventType())
+	}
+
+	payload := reflect.New(reflect.TypeOf(event))
+	if err := c.Unmarshal(decoded.Data, payload.Interface()); err != nil {
+		t.Fatalf("could not unmarshal %s: %v", event.GameEventType(), err)
+	}
+	return payload.Elem().Interface().(events.SerializableEvent)
+}
+
+// assertSameJSON compares events by their JSON encoding, which is the shape that every codec encodes events in
+func assertSameJSON(t *testing.T, expected, actual events.SerializableEvent) {
+	t.Helper()
+
+	expectedJSON, _ := json.Marshal(expected)
+	actualJSON, _ := json.Marshal(actual)
+	if !bytes.Equal(expectedJSON, actualJSON) {
+		t.Errorf("round trip of %s changed the event\nexpected: %s\nactual:   %s",
+			expected.GameEventType(), expectedJSON, actualJSON)
+	}
+}
+
+func TestRoundTripEveryEventType(t *testing.T) {
+	for name, c := range codecs {
+		for eventType := events.GameEventType(0); eventType <= events.MultiPartPayload; eventType++ {
+			prototype, ok := eventType.Prototype()
+			if !ok {
+				continue
+			}
+			t.Run(name+"/"+eventType.Name(), func(t *testing.T) {
+				assertSameJSON(t, prototype, roundTrip(t, c, prototype))
+			})
+		}
+	}
+}
+
+func TestRoundTripEventData(t *testing.T) {
+	user := model.User{ID: "1mZlaYVCTdKa1mxDE5JDGWQhyUr", Name: "Crème Brûlée"}
+	tests := []events.SerializableEvent{
+		events.ChatUserMessage(user, "héllo 👋🏽"),
+		events.DrawTempEvent{User: user, Line: model.Line{
+			Points:       []model.Point{{X: 0, Y: 1}, {X: 0.5, Y: 0.25}, {X: 0.123456789, Y: 1e-9}},
+			ColourIdx:    3,
+			ThicknessIdx: 2,
+		}},
+		events.MultiPartPayloadEvent{PayloadID: "p", Part: 1, Total: 2, Data: []byte{0x00, 0xff, 0xf6, 0x20}},
+		events.Nack("id", events.EventTypeDrawTempStop, events.Reject(events.ErrorTooManyPoints, "Too many points")),
+	}
+
+	for name, c := range codecs {
+		for _, event := range tests {
+			t.Run(name+"/"+event.GameEventType().Name(), func(t *testing.T) {
+				assertSameJSON(t, event, roundTrip(t, c, event))
+			})
+		}
+	}
+}
+
+func TestCBORDecodeEnvelope(t *testing.T) {
+	msg, _ := cbor.Marshal(map[string]interface{}{
+		"type": "chat",
+		"id":   "abc",
+		"data": map[string]interface{}{"message": "hi"},
+	})
+	event, err := CBOR.Decode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != events.EventTypeChat || event.ID != "abc" {
+		t.Fatalf("decoded %s with ID %q, expected chat with ID abc", event.Type, event.ID)
+	}
+
+	var chat events.ChatEvent
+	if err := CBOR.Unmarshal(event.Data, &chat); err != nil || chat.Message != "hi" {
+		t.Fatalf("decoded message %q: %v", chat.Message, err)
+	}
+}
+
+func TestCBORDecodeLegacyEventType(t *testing.T) {
+	msg, _ := cbor.Marshal(map[string]interface{}{"type": int(events.EventTypeReady), "data": nil})
+	event, err := CBOR.Decode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != events.EventTypeReady {
+		t.Fatalf("decoded %s, expected ready", event.Type)
+	}
+}
+
+func TestCBORDecodeRejectsMalformedMessages(t *testing.T) {
+	// {"type": "chat", "type": "ready"}
+	duplicateKeys := []byte{0xa2, 0x64, 't', 'y', 'p', 'e', 0x64, 'c', 'h', 'a', 't', 0x64, 't', 'y', 'p', 'e', 0x65,
+		'r', 'e', 'a', 'd', 'y'}
+	tests := map[string][]byte{
+		"empty":          {},
+		"truncated":      {0xa1, 0x64, 't', 'y'},
+		"not a map":      {0x83, 0x01, 0x02, 0x03},
+		"trailing bytes": append(CBOR.Encode(events.ReadyEvent{}, 0), 0x00),
+		"duplicate keys": duplicateKeys,
+	}
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := CBOR.Decode(msg); err == nil {
+				t.Errorf("decoded a malformed message")
+			}
+		})
+	}
+}
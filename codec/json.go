@@ -0,0 +1,37 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/kvnxiao/pictorio/events"
+)
+
+// jsonCodec sends events as JSON text messages
+type jsonCodec struct {
+	legacy bool
+}
+
+func (jsonCodec) Subprotocol() string {
+	return SubprotocolJSON
+}
+
+func (jsonCodec) Binary() bool {
+	return false
+}
+
+func (c jsonCodec) Encode(event events.SerializableEvent, seq uint64) []byte {
+	if c.legacy {
+		return events.ToLegacyJson(event, seq)
+	}
+	return events.ToJson(event, seq)
+}
+
+func (jsonCodec) Decode(msg []byte) (events.GameEvent, error) {
+	var event events.GameEvent
+	err := json.Unmarshal(msg, &event)
+	return event, err
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
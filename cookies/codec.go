@@ -1,13 +1,177 @@
 package cookies
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+)
+
+const (
+	valueSeparator     = "."
+	timestampSeparator = "|"
+	hashKeyContext     = "pictorio-cookie-hash"
+	encryptKeyContext  = "pictorio-cookie-encrypt"
 )
 
-func encode(input string) string {
-	return base64.URLEncoding.EncodeToString([]byte(input))
+var (
+	// ErrInvalidCookie is returned when a cookie value fails signature verification, cannot be decrypted, or has
+	// expired. Callers should treat the cookie as if it were never sent.
+	ErrInvalidCookie = errors.New("invalid or tampered cookie")
+	// ErrNoSecrets is returned when a codec is created without any secrets to sign cookies with
+	ErrNoSecrets = errors.New("at least one cookie secret is required")
+)
+
+// codecKey holds the keys derived from a single server secret
+type codecKey struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+// Codec signs and optionally encrypts cookie values. The first secret is used to encode new values, while all secrets
+// are accepted when decoding, so that secrets can be rotated without logging out every user.
+type Codec struct {
+	keys    []codecKey
+	encrypt bool
+	maxAge  time.Duration
+	clock   clock.Clock
 }
 
-func decode(input string) ([]byte, error) {
-	return base64.URLEncoding.DecodeString(input)
+func deriveKey(secret []byte, context string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(context))
+	return mac.Sum(nil)
+}
+
+// NewCodec creates a cookie codec from a list of secrets ordered from newest to oldest. Values older than maxAge are
+// rejected when decoding, unless maxAge is zero.
+func NewCodec(secrets [][]byte, encrypt bool, maxAge time.Duration) (*Codec, error) {
+	if len(secrets) == 0 {
+		return nil, ErrNoSecrets
+	}
+
+	keys := make([]codecKey, len(secrets))
+	for i, secret := range secrets {
+		if len(secret) == 0 {
+			return nil, errors.New("cookie secrets must not be empty")
+		}
+		block, err := aes.NewCipher(deriveKey(secret, encryptKeyContext))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = codecKey{
+			hashKey: deriveKey(secret, hashKeyContext),
+			aead:    aead,
+		}
+	}
+
+	return &Codec{
+		keys:    keys,
+		encrypt: encrypt,
+		maxAge:  maxAge,
+		clock:   clock.Real(),
+	}, nil
+}
+
+// RandomSecret generates a random secret, for use when no secret has been configured for the server.
+func RandomSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// sign computes the signature of a cookie value, which is bound to the cookie's name so that the value of one cookie
+// cannot be swapped in for another
+func sign(hashKey []byte, name string, token string) []byte {
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write([]byte(name))
+	mac.Write([]byte(timestampSeparator))
+	mac.Write([]byte(token))
+	return mac.Sum(nil)
+}
+
+// Encode signs (and encrypts, if enabled) a cookie value with the newest secret.
+func (c *Codec) Encode(name string, value string) (string, error) {
+	key := c.keys[0]
+	payload := []byte(strconv.FormatInt(c.clock.Now().Unix(), 10) + timestampSeparator + value)
+
+	if c.encrypt {
+		nonce := make([]byte, key.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		payload = key.aead.Seal(nonce, nonce, payload, []byte(name))
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(payload)
+	signature := base64.RawURLEncoding.EncodeToString(sign(key.hashKey, name, token))
+	return token + valueSeparator + signature, nil
+}
+
+// Decode verifies a cookie value against every configured secret and returns the original value.
+func (c *Codec) Decode(name string, encoded string) (string, error) {
+	sepIndex := strings.LastIndex(encoded, valueSeparator)
+	if sepIndex < 0 {
+		return "", ErrInvalidCookie
+	}
+	token := encoded[:sepIndex]
+	signature, err := base64.RawURLEncoding.DecodeString(encoded[sepIndex+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range c.keys {
+		if !hmac.Equal(signature, sign(key.hashKey, name, token)) {
+			continue
+		}
+		return c.open(key, name, token)
+	}
+	return "", ErrInvalidCookie
+}
+
+// open decodes a token whose signature has already been verified with the provided key
+func (c *Codec) open(key codecKey, name string, token string) (string, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	if c.encrypt {
+		nonceSize := key.aead.NonceSize()
+		if len(payload) < nonceSize {
+			return "", ErrInvalidCookie
+		}
+		payload, err = key.aead.Open(nil, payload[:nonceSize], payload[nonceSize:], []byte(name))
+		if err != nil {
+			return "", ErrInvalidCookie
+		}
+	}
+
+	parts := strings.SplitN(string(payload), timestampSeparator, 2)
+	if len(parts) != 2 {
+		return "", ErrInvalidCookie
+	}
+	issuedAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	if c.maxAge > 0 && c.clock.Now().Sub(time.Unix(issuedAt, 0)) > c.maxAge {
+		return "", ErrInvalidCookie
+	}
+
+	return parts[1], nil
 }
@@ -0,0 +1,150 @@
+package cookies
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+)
+
+const (
+	testName  = "uid"
+	testValue = "1mZlaYVCTdKa1mxDE5JDGWQhyUr"
+)
+
+var (
+	newSecret = []byte("new secret")
+	oldSecret = []byte("old secret")
+)
+
+func newTestCodec(t *testing.T, encrypt bool, maxAge time.Duration, secrets ...[]byte) *Codec {
+	t.Helper()
+
+	c, err := NewCodec(secrets, encrypt, maxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func encode(t *testing.T, c *Codec, value string) string {
+	t.Helper()
+
+	encoded, err := c.Encode(testName, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		c := newTestCodec(t, encrypt, time.Hour, newSecret)
+		encoded := encode(t, c, testValue)
+		if encrypt && strings.Contains(encoded, testValue) {
+			t.Errorf("encrypted cookie %q contains its value", encoded)
+		}
+
+		value, err := c.Decode(testName, encoded)
+		if err != nil || value != testValue {
+			t.Errorf("decoded %q with error %v, expected %q (encrypted: %v)", value, err, testValue, encrypt)
+		}
+		// The value is bound to the cookie's name
+		if _, err := c.Decode("name", encoded); err != ErrInvalidCookie {
+			t.Errorf("decoded the value of another cookie with error %v (encrypted: %v)", err, encrypt)
+		}
+	}
+}
+
+func TestTamperedCookieIsRejected(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		c := newTestCodec(t, encrypt, time.Hour, newSecret)
+		encoded := encode(t, c, testValue)
+		separator := strings.LastIndex(encoded, valueSeparator)
+		token, signature := encoded[:separator], encoded[separator+1:]
+		flip := func(s string, i int) string {
+			b := []byte(s)
+			if b[i] == 'A' {
+				b[i] = 'B'
+			} else {
+				b[i] = 'A'
+			}
+			return string(b)
+		}
+
+		tampered := map[string]string{
+			"signature":         token + valueSeparator + flip(signature, 0),
+			"token":             flip(token, 0) + valueSeparator + signature,
+			"missing signature": token,
+			"empty":             "",
+			"other signature":   encode(t, c, "other")[:separator] + valueSeparator + signature,
+		}
+		for name, encoded := range tampered {
+			if value, err := c.Decode(testName, encoded); err != ErrInvalidCookie {
+				t.Errorf("decoded %q from a cookie with a tampered %s (encrypted: %v)", value, name, encrypt)
+			}
+		}
+	}
+}
+
+func TestRotatedSecret(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		encoded := encode(t, newTestCodec(t, encrypt, time.Hour, oldSecret), testValue)
+
+		rotated := newTestCodec(t, encrypt, time.Hour, newSecret, oldSecret)
+		if value, err := rotated.Decode(testName, encoded); err != nil || value != testValue {
+			t.Errorf("decoded %q with error %v after rotating the secret (encrypted: %v)", value, err, encrypt)
+		}
+		// New cookies are signed with the newest secret
+		newest := newTestCodec(t, encrypt, time.Hour, newSecret)
+		if _, err := newest.Decode(testName, encode(t, rotated, testValue)); err != nil {
+			t.Errorf("cookie was not signed with the newest secret: %v (encrypted: %v)", err, encrypt)
+		}
+
+		// Cookies signed with a secret that has been removed are rejected
+		if _, err := newest.Decode(testName, encoded); err != ErrInvalidCookie {
+			t.Errorf("decoded a cookie signed with a removed secret (encrypted: %v)", encrypt)
+		}
+	}
+}
+
+func TestWrongEncryptionKey(t *testing.T) {
+	c := newTestCodec(t, true, time.Hour, newSecret)
+	other := newTestCodec(t, true, time.Hour, oldSecret)
+	encoded := encode(t, c, testValue)
+
+	// The signature is valid, but the value cannot be decrypted with the wrong key
+	wrongKey := &Codec{
+		keys:    []codecKey{{hashKey: c.keys[0].hashKey, aead: other.keys[0].aead}},
+		encrypt: true,
+		clock:   clock.Real(),
+	}
+	if value, err := wrongKey.Decode(testName, encoded); err != ErrInvalidCookie {
+		t.Errorf("decrypted %q with the wrong key", value)
+	}
+}
+
+func TestExpiredCookieIsRejected(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		c := newTestCodec(t, encrypt, time.Hour, newSecret)
+		clk := clock.NewManual(time.Unix(1600000000, 0))
+		c.clock = clk
+		encoded := encode(t, c, testValue)
+
+		clk.Advance(time.Hour)
+		if _, err := c.Decode(testName, encoded); err != nil {
+			t.Errorf("cookie expired before its max age: %v (encrypted: %v)", err, encrypt)
+		}
+		clk.Advance(time.Second)
+		if _, err := c.Decode(testName, encoded); err != ErrInvalidCookie {
+			t.Errorf("decoded a cookie older than its max age (encrypted: %v)", encrypt)
+		}
+
+		// Cookies never expire without a max age
+		c.maxAge = 0
+		if _, err := c.Decode(testName, encoded); err != nil {
+			t.Errorf("cookie expired without a max age: %v (encrypted: %v)", err, encrypt)
+		}
+	}
+}
@@ -2,50 +2,110 @@ package cookies
 
 import (
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 const (
 	cookieUserID   = "uid"
 	cookieUserName = "uname"
+
+	defaultMaxAge = 365 * 24 * time.Hour
 )
 
-func SetUserID(w http.ResponseWriter, id string) {
-	cookie := &http.Cookie{
-		Name:  cookieUserID,
-		Path:  "/",
-		Value: encode(id),
-	}
-	http.SetCookie(w, cookie)
+// Options configures how the session cookies are signed and which attributes they are set with.
+type Options struct {
+	// Secrets are ordered from newest to oldest. The newest secret signs new cookies, while older secrets are only
+	// used to verify cookies issued before a rotation.
+	Secrets [][]byte
+	// Encrypt hides cookie values from the client in addition to signing them
+	Encrypt bool
+	// Secure restricts cookies to HTTPS connections
+	Secure bool
+	// MaxAge is how long a cookie is valid for, defaulting to one year
+	MaxAge time.Duration
 }
 
-func GetUserID(r *http.Request) (string, error) {
-	c, err := r.Cookie(cookieUserID)
-	if err != nil {
-		switch err {
-		case http.ErrNoCookie:
-			return "", nil
-		default:
-			return "", err
+var (
+	mu     sync.RWMutex
+	codec  *Codec
+	secure bool
+	maxAge = defaultMaxAge
+)
+
+// Configure sets up the codec used to sign session cookies. If no secrets are provided, a random secret is generated,
+// which means all sessions are invalidated whenever the server restarts.
+func Configure(opts Options) error {
+	secrets := opts.Secrets
+	if len(secrets) == 0 {
+		log.Warn().Msg("No cookie secret configured, generating a random secret. Sessions will not survive a restart.")
+		secret, err := RandomSecret()
+		if err != nil {
+			return err
 		}
+		secrets = [][]byte{secret}
 	}
-	value, err := decode(c.Value)
+
+	cookieMaxAge := opts.MaxAge
+	if cookieMaxAge <= 0 {
+		cookieMaxAge = defaultMaxAge
+	}
+
+	c, err := NewCodec(secrets, opts.Encrypt, cookieMaxAge)
 	if err != nil {
-		return "", err
+		return err
 	}
-	return string(value), nil
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	codec = c
+	secure = opts.Secure
+	maxAge = cookieMaxAge
+	return nil
 }
 
-func SetUserName(w http.ResponseWriter, name string) {
-	cookie := &http.Cookie{
-		Name:  cookieUserName,
-		Path:  "/",
-		Value: encode(name),
+func currentCodec() *Codec {
+	mu.RLock()
+	c := codec
+	mu.RUnlock()
+
+	if c == nil {
+		// Fall back to a random secret if the server never configured one
+		if err := Configure(Options{}); err != nil {
+			log.Fatal().Err(err).Msg("Unable to configure cookie codec")
+		}
+		return currentCodec()
 	}
-	http.SetCookie(w, cookie)
+	return c
 }
 
-func GetUserName(r *http.Request) (string, error) {
-	c, err := r.Cookie(cookieUserName)
+func setCookie(w http.ResponseWriter, name string, value string) {
+	encoded, err := currentCodec().Encode(name, value)
+	if err != nil {
+		log.Error().Err(err).Str("cookie", name).Msg("Unable to encode cookie")
+		return
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		Value:    encoded,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// getCookie returns an empty string if the cookie is not present, or ErrInvalidCookie if it has been tampered with
+func getCookie(r *http.Request, name string) (string, error) {
+	c, err := r.Cookie(name)
 	if err != nil {
 		switch err {
 		case http.ErrNoCookie:
@@ -54,9 +114,21 @@ func GetUserName(r *http.Request) (string, error) {
 			return "", err
 		}
 	}
-	value, err := decode(c.Value)
-	if err != nil {
-		return "", err
-	}
-	return string(value), nil
+	return currentCodec().Decode(name, c.Value)
+}
+
+func SetUserID(w http.ResponseWriter, id string) {
+	setCookie(w, cookieUserID, id)
+}
+
+func GetUserID(r *http.Request) (string, error) {
+	return getCookie(r, cookieUserID)
+}
+
+func SetUserName(w http.ResponseWriter, name string) {
+	setCookie(w, cookieUserName, name)
+}
+
+func GetUserName(r *http.Request) (string, error) {
+	return getCookie(r, cookieUserName)
 }
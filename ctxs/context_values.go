@@ -3,6 +3,7 @@ package ctxs
 import (
 	"context"
 
+	"github.com/kvnxiao/pictorio/events"
 	"github.com/segmentio/ksuid"
 )
 
@@ -12,6 +13,8 @@ const (
 	KeyRoomID key = iota
 	KeyUserID
 	KeyUserName
+	KeyLastSeq
+	KeyHandshake
 )
 
 // RoomID gets the roomID string value from the context.
@@ -26,6 +29,18 @@ func UserName(ctx context.Context) (string, bool) {
 	return v, ok
 }
 
+// LastSeq gets the sequence number of the last event a reconnecting user has received from the context.
+func LastSeq(ctx context.Context) (uint64, bool) {
+	v, ok := ctx.Value(KeyLastSeq).(uint64)
+	return v, ok
+}
+
+// Handshake gets the protocol version and capabilities declared by the user's client from the context.
+func Handshake(ctx context.Context) (events.Handshake, bool) {
+	v, ok := ctx.Value(KeyHandshake).(events.Handshake)
+	return v, ok
+}
+
 // UserID gets the ID of the user from the context.
 func UserID(ctx context.Context) (ksuid.KSUID, bool) {
 	v, ok := ctx.Value(KeyUserID).(ksuid.KSUID)
@@ -0,0 +1,83 @@
+package events
+
+// ErrorCode is a machine-readable reason for a client action being rejected
+type ErrorCode string
+
+const (
+	ErrorMalformedEvent      ErrorCode = "malformed_event"
+	ErrorInvalidEvent        ErrorCode = "invalid_event"
+	ErrorUnknownEvent        ErrorCode = "unknown_event"
+	ErrorServerSourcedEvent  ErrorCode = "server_sourced_event"
+	ErrorNotInRoom           ErrorCode = "not_in_room"
+	ErrorNotRoomLeader       ErrorCode = "not_room_leader"
+	ErrorNotDrawer           ErrorCode = "not_drawer"
+	ErrorInvalidGameStatus   ErrorCode = "invalid_game_status"
+	ErrorInvalidTurnPhase    ErrorCode = "invalid_turn_phase"
+	ErrorPlayersNotReady     ErrorCode = "players_not_ready"
+	ErrorInvalidWordIndex    ErrorCode = "invalid_word_index"
+	ErrorInvalidSettings     ErrorCode = "invalid_settings"
+	ErrorInvalidWords        ErrorCode = "invalid_words"
+	ErrorInvalidPasscode     ErrorCode = "invalid_passcode"
+	ErrorInvalidTarget       ErrorCode = "invalid_target"
+	ErrorNothingToUndoOrRedo ErrorCode = "nothing_to_undo_or_redo"
+	ErrorInvalidDrawing      ErrorCode = "invalid_drawing"
+	ErrorTooManyPoints       ErrorCode = "too_many_points"
+	ErrorTooManyStrokes      ErrorCode = "too_many_strokes"
+	ErrorPayloadTooLarge     ErrorCode = "payload_too_large"
+	ErrorPayloadExpired      ErrorCode = "payload_expired"
+)
+
+// ActionError is returned by the server's event listeners when a client action is rejected
+type ActionError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *ActionError) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// Reject creates an ActionError which rejects a client action for the given reason
+func Reject(code ErrorCode, message string) *ActionError {
+	return &ActionError{Code: code, Message: message}
+}
+
+// AckEvent is the server-sourced event which tells a client whether one of its events was accepted. It is only sent to
+// the connection that the event was read from.
+//
+// Rejected events are always acknowledged with OK set to false, along with the error code and a human-readable message.
+// Accepted events are only acknowledged if the client supplied an ID in the event's envelope.
+//
+// ID is the ID supplied by the client in the event's envelope, which correlates the acknowledgement to the event. A
+// rejected MultiPartPayloadEvent is acknowledged with its payload ID instead.
+type AckEvent struct {
+	ID      string        `json:"id,omitempty"`
+	Event   GameEventType `json:"event"`
+	OK      bool          `json:"ok"`
+	Code    ErrorCode     `json:"code,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+func (e AckEvent) GameEventType() GameEventType {
+	return EventTypeAck
+}
+
+// Ack acknowledges that a client's event was accepted
+func Ack(id string, eventType GameEventType) AckEvent {
+	return AckEvent{
+		ID:    id,
+		Event: eventType,
+		OK:    true,
+	}
+}
+
+// Nack tells a client that its event was rejected
+func Nack(id string, eventType GameEventType, err *ActionError) AckEvent {
+	return AckEvent{
+		ID:      id,
+		Event:   eventType,
+		OK:      false,
+		Code:    err.Code,
+		Message: err.Message,
+	}
+}
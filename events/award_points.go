@@ -1,26 +1,17 @@
 package events
 
 import (
-	"encoding/json"
-
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
+// AwardPointsEvent is broadcasted when a player guesses the word, with the points awarded to the guesser and the
+// drawer, and the breakdown of the points by the room's scoring rule
 type AwardPointsEvent struct {
-	Guesser       model.User `json:"guesser"`
-	GuesserPoints int        `json:"guesserPoints"`
-	Drawer        model.User `json:"drawer"`
-	DrawerPoints  int        `json:"drawerPoints"`
-}
-
-func (e AwardPointsEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
+	Guesser       model.User              `json:"guesser"`
+	GuesserPoints int                     `json:"guesserPoints"`
+	Drawer        model.User              `json:"drawer"`
+	DrawerPoints  int                     `json:"drawerPoints"`
+	Breakdown     []model.PointsBreakdown `json:"breakdown"`
 }
 
 func (e AwardPointsEvent) GameEventType() GameEventType {
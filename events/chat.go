@@ -1,10 +1,11 @@
 package events
 
 import (
-	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type ChatEventType int
@@ -15,13 +16,22 @@ const (
 	ChatEventJoin
 	ChatEventLeave
 	ChatEventGuessed
+	ChatEventKicked
+	ChatEventBanned
+	ChatEventRoomLeader
 )
 
+// MaxChatMessageLength is the maximum number of characters in a chat message sent by a user
+const MaxChatMessageLength = 256
+
 const (
 	userJoinedMsg      = "has joined the room."
 	userLeftMsg        = "has left the room."
 	userGuessedMsg     = "has guessed the word."
 	spectatorJoinedMsg = "has joined the room as a spectator."
+	userKickedMsg      = "was kicked from the room."
+	userBannedMsg      = "was banned from the room."
+	userRoomLeaderMsg  = "is now the room leader."
 
 	formatSystem     = "%m"
 	formatUser       = "%u: %m"
@@ -35,19 +45,21 @@ type ChatEvent struct {
 	Type    ChatEventType `json:"type"`
 }
 
-func (e ChatEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e ChatEvent) GameEventType() GameEventType {
 	return EventTypeChat
 }
 
+func (e ChatEvent) Validate() error {
+	if strings.TrimSpace(e.Message) == "" {
+		return Reject(ErrorInvalidEvent, "Chat message must not be empty")
+	}
+	if utf8.RuneCountInString(e.Message) > MaxChatMessageLength {
+		return Reject(ErrorInvalidEvent,
+			"Chat message must not be longer than "+strconv.Itoa(MaxChatMessageLength)+" characters")
+	}
+	return nil
+}
+
 func ChatUserJoined(user model.User, isSpectator bool) ChatEvent {
 	msg := userJoinedMsg
 	if isSpectator {
@@ -70,6 +82,33 @@ func ChatUserLeft(user model.User) ChatEvent {
 	}
 }
 
+func ChatUserKicked(user model.User) ChatEvent {
+	return ChatEvent{
+		User:    user,
+		Message: userKickedMsg,
+		Format:  formatUserAction,
+		Type:    ChatEventKicked,
+	}
+}
+
+func ChatUserBanned(user model.User) ChatEvent {
+	return ChatEvent{
+		User:    user,
+		Message: userBannedMsg,
+		Format:  formatUserAction,
+		Type:    ChatEventBanned,
+	}
+}
+
+func ChatUserRoomLeader(user model.User) ChatEvent {
+	return ChatEvent{
+		User:    user,
+		Message: userRoomLeaderMsg,
+		Format:  formatUserAction,
+		Type:    ChatEventRoomLeader,
+	}
+}
+
 func ChatUserGuessed(user model.User) ChatEvent {
 	return ChatEvent{
 		User:    user,
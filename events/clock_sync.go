@@ -0,0 +1,42 @@
+package events
+
+import "time"
+
+// ClockSyncEvent is the bi-directional ping / pong event which lets clients estimate the offset between their clock
+// and the server's clock, so that they can render countdowns from the deadlines sent by the server
+//
+// From client:
+// - ClientTime is the client's current time in Unix milliseconds
+//
+// To client:
+// - ClientTime is echoed back unchanged
+// - ServerTime is the server's time in Unix milliseconds upon receiving the event
+//
+// The client can then estimate its clock offset as ServerTime + RTT/2 - now, where RTT = now - ClientTime.
+type ClockSyncEvent struct {
+	ClientTime int64 `json:"clientTime"`
+	ServerTime int64 `json:"serverTime"`
+}
+
+func (e ClockSyncEvent) GameEventType() GameEventType {
+	return EventTypeClockSync
+}
+
+// UnixMillis converts a time into Unix milliseconds, which is how deadlines and timestamps are sent to clients. The
+// zero time is converted to zero, so that it is omitted from events.
+func UnixMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// SecondsLeft rounds the time left until a deadline up to whole seconds, for clients which render the countdown from
+// the time left instead of the deadline
+func SecondsLeft(deadline time.Time, now time.Time) int {
+	left := deadline.Sub(now)
+	if left <= 0 {
+		return 0
+	}
+	return int((left + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"github.com/kvnxiao/pictorio/model"
+	"github.com/kvnxiao/pictorio/words"
+)
+
+// CustomWordsEvent is sent by the room leader to upload a custom word list for the room, which replaces any previous
+// list. An empty list removes the room's custom words. The event is broadcasted back to all players with the
+// validated list, omitting the words for players other than the room leader if they are hidden.
+type CustomWordsEvent struct {
+	User   model.User            `json:"user"`
+	Words  []string              `json:"words"`
+	Mode   model.CustomWordsMode `json:"mode"`
+	Hidden bool                  `json:"hidden"`
+	// Count is the number of custom words after duplicates have been removed, which is set by the server
+	Count int `json:"count"`
+}
+
+func (e CustomWordsEvent) GameEventType() GameEventType {
+	return EventTypeCustomWords
+}
+
+func (e CustomWordsEvent) Validate() error {
+	if _, err := e.List(); err != nil {
+		return Reject(ErrorInvalidWords, err.Error())
+	}
+	return nil
+}
+
+// List returns the validated custom word list. The mode defaults to mixing the custom words with the room's word
+// packs.
+func (e CustomWordsEvent) List() (words.CustomList, error) {
+	mode := e.Mode
+	if mode == "" {
+		mode = model.CustomWordsMixed
+	}
+	return words.NewCustomList(e.Words, mode, e.Hidden)
+}
+
+// CustomWordsUploaded creates the event broadcasted for a room's new custom word list. The words are included only if
+// they are not hidden, or if includeHidden is true.
+func CustomWordsUploaded(user model.User, list words.CustomList, includeHidden bool) CustomWordsEvent {
+	event := CustomWordsEvent{
+		User:   user,
+		Mode:   list.Mode,
+		Hidden: list.Hidden,
+		Count:  len(list.Words),
+	}
+	if !list.Hidden || includeHidden {
+		event.Words = list.Words
+	}
+	return event
+}
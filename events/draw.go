@@ -1,10 +1,10 @@
 package events
 
 import (
-	"encoding/json"
+	"math"
+	"strconv"
 
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type DrawEventType int
@@ -20,15 +20,51 @@ type DrawEvent struct {
 	Type DrawEventType `json:"type"`
 }
 
-func (e DrawEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
+func (e DrawEvent) GameEventType() GameEventType {
+	return EventTypeDraw
+}
+
+func (e DrawEvent) Validate() error {
+	switch e.Type {
+	case Undo, Redo, Clear:
 		return nil
+	default:
+		return Reject(ErrorInvalidEvent, "Unknown "+e.GameEventType().String()+" type")
 	}
-	return eventBytes
 }
 
-func (e DrawEvent) GameEventType() GameEventType {
-	return EventTypeDraw
+// validateLine checks that a line's points lie within normalized canvas space, and that it is drawn with a colour and
+// thickness from the palette. The number of points is limited by the drawing history, since a line is drawn across
+// several events.
+func validateLine(line model.Line) error {
+	for _, point := range line.Points {
+		if !inCanvas(point.X) || !inCanvas(point.Y) {
+			return Reject(ErrorInvalidDrawing, "Points must be within the canvas, between 0 and 1")
+		}
+	}
+	if err := validateColour(line.ColourIdx); err != nil {
+		return err
+	}
+	return validateThickness(line.ThicknessIdx)
+}
+
+// inCanvas returns true if a coordinate is within normalized canvas space, which excludes NaN and infinities
+func inCanvas(coordinate float64) bool {
+	return !math.IsNaN(coordinate) && coordinate >= 0 && coordinate <= 1
+}
+
+func validateColour(colourIdx int) error {
+	if colourIdx < 0 || colourIdx >= model.PaletteSize {
+		return Reject(ErrorInvalidDrawing,
+			"Colour index must be between 0 and "+strconv.Itoa(model.PaletteSize-1))
+	}
+	return nil
+}
+
+func validateThickness(thicknessIdx int) error {
+	if thicknessIdx < 0 || thicknessIdx >= model.ThicknessCount {
+		return Reject(ErrorInvalidDrawing,
+			"Thickness index must be between 0 and "+strconv.Itoa(model.ThicknessCount-1))
+	}
+	return nil
 }
@@ -0,0 +1,17 @@
+package events
+
+import (
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// DrawSnapshotEvent replaces the drawing of a user who resumed their connection, since the temporary line segments
+// sent while drawing are not replayed
+type DrawSnapshotEvent struct {
+	Lines []model.Line `json:"lines"`
+	// Temp is the line being drawn, if any
+	Temp *model.Line `json:"temp,omitempty"`
+}
+
+func (e DrawSnapshotEvent) GameEventType() GameEventType {
+	return EventTypeDrawSnapshot
+}
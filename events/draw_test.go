@@ -0,0 +1,48 @@
+package events
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/kvnxiao/pictorio/model"
+)
+
+func TestValidateLine(t *testing.T) {
+	point := func(x, y float64) []model.Point {
+		return []model.Point{{X: 0.5, Y: 0.5}, {X: x, Y: y}}
+	}
+	tests := map[string]struct {
+		line  model.Line
+		valid bool
+	}{
+		"valid":                  {model.Line{Points: point(0, 1), ColourIdx: 1, ThicknessIdx: 1}, true},
+		"no points":              {model.Line{}, true},
+		"canvas corners":         {model.Line{Points: point(1, 0)}, true},
+		"nan":                    {model.Line{Points: point(math.NaN(), 0.5)}, false},
+		"positive infinity":      {model.Line{Points: point(0.5, math.Inf(1))}, false},
+		"negative infinity":      {model.Line{Points: point(math.Inf(-1), 0.5)}, false},
+		"negative coordinate":    {model.Line{Points: point(-0.01, 0.5)}, false},
+		"coordinate above one":   {model.Line{Points: point(0.5, 1.01)}, false},
+		"negative colour":        {model.Line{ColourIdx: -1}, false},
+		"colour out of palette":  {model.Line{ColourIdx: model.PaletteSize}, false},
+		"last colour":            {model.Line{ColourIdx: model.PaletteSize - 1}, true},
+		"negative thickness":     {model.Line{ThicknessIdx: -1}, false},
+		"thickness out of range": {model.Line{ThicknessIdx: model.ThicknessCount}, false},
+		"last thickness":         {model.Line{ThicknessIdx: model.ThicknessCount - 1}, true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, event := range []Validator{DrawTempEvent{Line: test.line}, DrawTempStopEvent{Line: test.line}} {
+				err := event.Validate()
+				if test.valid && err != nil {
+					t.Errorf("rejected a valid line: %v", err)
+				}
+				var actionErr *ActionError
+				if !test.valid && (!errors.As(err, &actionErr) || actionErr.Code != ErrorInvalidDrawing) {
+					t.Errorf("accepted an invalid line, with error %v", err)
+				}
+			}
+		})
+	}
+}
@@ -2,36 +2,199 @@ package events
 
 import (
 	"encoding/json"
+	"strconv"
+
+	"github.com/fxamacker/cbor/v2"
 )
 
+// GameEventType identifies the type of a GameEvent. Event types are sent on the wire by their stable names, while
+// clients using the legacy protocol send and receive their numbers. The numbers are part of the legacy protocol, and
+// so must never be changed or reused.
 type GameEventType int
 
 const (
-	EventTypeUserJoinLeave     GameEventType = iota // server-sourced
-	EventTypeRehydrate                              // server-sourced
-	EventTypeChat                                   // bi-directional
-	EventTypeDraw                                   // bi-directional
-	EventTypeReady                                  // bi-directional
-	EventTypeStartGame                              // server-sourced
-	EventTypeStartGameIssued                        // client-sourced
-	EventTypeTurnNextPlayer                         // server-sourced
-	EventTypeTurnWordSelection                      // server-sourced
-	EventTypeTurnWordSelected                       // client-sourced
-	EventTypeTurnDrawing                            // server-sourced
-	EventTypeTurnEnd                                // server-sourced
-	EventTypeAwardPoints                            // server-sourced
-	EventTypeGameOver                               // server-sourced
-	EventTypeNewGameIssued                          // client-sourced
-	EventTypeNewGameReset                           // server-sourced
-	EventTypeDrawTemp                               // client-sourced
-	EventTypeDrawSelectColour						// client-sourced
-	EventTypeDrawSelectThickness					// client-sourced
-	EventTypeDrawTempStop							// client-sourced
+	EventTypeUserJoinLeave       GameEventType = 0  // server-sourced
+	EventTypeRehydrate           GameEventType = 1  // server-sourced
+	EventTypeChat                GameEventType = 2  // bi-directional
+	EventTypeDraw                GameEventType = 3  // bi-directional
+	EventTypeReady               GameEventType = 4  // bi-directional
+	EventTypeStartGame           GameEventType = 5  // server-sourced
+	EventTypeStartGameIssued     GameEventType = 6  // client-sourced
+	EventTypeTurnNextPlayer      GameEventType = 7  // server-sourced
+	EventTypeTurnWordSelection   GameEventType = 8  // server-sourced
+	EventTypeTurnWordSelected    GameEventType = 9  // client-sourced
+	EventTypeTurnDrawing         GameEventType = 10 // server-sourced
+	EventTypeTurnEnd             GameEventType = 11 // server-sourced
+	EventTypeAwardPoints         GameEventType = 12 // server-sourced
+	EventTypeGameOver            GameEventType = 13 // server-sourced
+	EventTypeNewGameIssued       GameEventType = 14 // client-sourced
+	EventTypeNewGameReset        GameEventType = 15 // server-sourced
+	EventTypeDrawTemp            GameEventType = 16 // client-sourced
+	EventTypeDrawSelectColour    GameEventType = 17 // client-sourced
+	EventTypeDrawSelectThickness GameEventType = 18 // client-sourced
+	EventTypeDrawTempStop        GameEventType = 19 // client-sourced
+	EventTypeUpdateSettings      GameEventType = 20 // bi-directional
+	EventTypeRoomPasscode        GameEventType = 21 // bi-directional
+	EventTypeKickPlayer          GameEventType = 22 // client-sourced
+	EventTypeBanPlayer           GameEventType = 23 // client-sourced
+	EventTypeRoomLeader          GameEventType = 24 // bi-directional
+	EventTypeClockSync           GameEventType = 25 // bi-directional
+	EventTypeAck                 GameEventType = 26 // server-sourced
+	EventTypeWelcome             GameEventType = 27 // server-sourced
+	EventTypeCustomWords         GameEventType = 28 // bi-directional
+	EventTypeDrawSnapshot        GameEventType = 29 // server-sourced
 
 	// For receiving chunked data over WebSockets
 	MultiPartPayload GameEventType = 99
+
+	// EventTypeUnknown is the type of incoming events with an unrecognized name
+	EventTypeUnknown GameEventType = -1
+)
+
+// Source is the side of the connection which sends an event type
+type Source int
+
+const (
+	ServerSourced Source = iota
+	ClientSourced
+	BiDirectional
 )
 
+// eventTypeInfo describes an event type
+type eventTypeInfo struct {
+	// name is the stable name of the event type on the wire, which must never be changed or reused
+	name   string
+	source Source
+	// prototype is a zero value of the event type's payload
+	prototype SerializableEvent
+}
+
+var eventTypes = map[GameEventType]eventTypeInfo{
+	EventTypeUserJoinLeave:       {"user_join_leave", ServerSourced, UserJoinLeaveEvent{}},
+	EventTypeRehydrate:           {"rehydrate", ServerSourced, RehydrateEvent{}},
+	EventTypeChat:                {"chat", BiDirectional, ChatEvent{}},
+	EventTypeDraw:                {"draw", BiDirectional, DrawEvent{}},
+	EventTypeReady:               {"ready", BiDirectional, ReadyEvent{}},
+	EventTypeStartGame:           {"start_game", ServerSourced, StartGameEvent{}},
+	EventTypeStartGameIssued:     {"start_game_issued", ClientSourced, StartGameIssuedEvent{}},
+	EventTypeTurnNextPlayer:      {"turn_next_player", ServerSourced, TurnNextPlayerEvent{}},
+	EventTypeTurnWordSelection:   {"turn_word_selection", ServerSourced, TurnWordSelectionEvent{}},
+	EventTypeTurnWordSelected:    {"turn_word_selected", ClientSourced, TurnWordSelectedEvent{}},
+	EventTypeTurnDrawing:         {"turn_drawing", ServerSourced, TurnDrawingEvent{}},
+	EventTypeTurnEnd:             {"turn_end", ServerSourced, TurnEndEvent{}},
+	EventTypeAwardPoints:         {"award_points", ServerSourced, AwardPointsEvent{}},
+	EventTypeGameOver:            {"game_over", ServerSourced, GameOverEvent{}},
+	EventTypeNewGameIssued:       {"new_game_issued", ClientSourced, NewGameIssuedEvent{}},
+	EventTypeNewGameReset:        {"new_game_reset", ServerSourced, NewGameResetEvent{}},
+	EventTypeDrawTemp:            {"draw_temp", ClientSourced, DrawTempEvent{}},
+	EventTypeDrawSelectColour:    {"draw_select_colour", ClientSourced, DrawSelectColourEvent{}},
+	EventTypeDrawSelectThickness: {"draw_select_thickness", ClientSourced, DrawSelectThicknessEvent{}},
+	EventTypeDrawTempStop:        {"draw_temp_stop", ClientSourced, DrawTempStopEvent{}},
+	EventTypeUpdateSettings:      {"update_settings", BiDirectional, UpdateSettingsEvent{}},
+	EventTypeRoomPasscode:        {"room_passcode", BiDirectional, RoomPasscodeEvent{}},
+	EventTypeKickPlayer:          {"kick_player", ClientSourced, KickPlayerEvent{}},
+	EventTypeBanPlayer:           {"ban_player", ClientSourced, BanPlayerEvent{}},
+	EventTypeRoomLeader:          {"room_leader", BiDirectional, RoomLeaderEvent{}},
+	EventTypeClockSync:           {"clock_sync", BiDirectional, ClockSyncEvent{}},
+	EventTypeAck:                 {"ack", ServerSourced, AckEvent{}},
+	EventTypeWelcome:             {"welcome", ServerSourced, WelcomeEvent{}},
+	EventTypeCustomWords:         {"custom_words", BiDirectional, CustomWordsEvent{}},
+	EventTypeDrawSnapshot:        {"draw_snapshot", ServerSourced, DrawSnapshotEvent{}},
+	MultiPartPayload:             {"multi_part_payload", BiDirectional, MultiPartPayloadEvent{}},
+}
+
+var eventTypesByName = func() map[string]GameEventType {
+	byName := make(map[string]GameEventType, len(eventTypes))
+	for eventType, info := range eventTypes {
+		byName[info.name] = eventType
+	}
+	return byName
+}()
+
+// Name returns the stable name of the event type on the wire, or its number if the event type is not recognized
+func (e GameEventType) Name() string {
+	if info, ok := eventTypes[e]; ok {
+		return info.name
+	}
+	return strconv.Itoa(int(e))
+}
+
+// Source returns the side of the connection which sends the event type, or false if the event type is not recognized
+func (e GameEventType) Source() (Source, bool) {
+	info, ok := eventTypes[e]
+	return info.source, ok
+}
+
+// Prototype returns a zero value of the event type's payload, or false if the event type is not recognized
+func (e GameEventType) Prototype() (SerializableEvent, bool) {
+	info, ok := eventTypes[e]
+	return info.prototype, ok
+}
+
+// ParseGameEventType looks up an event type by its stable name, returning EventTypeUnknown if the name is not
+// recognized
+func ParseGameEventType(name string) GameEventType {
+	if eventType, ok := eventTypesByName[name]; ok {
+		return eventType
+	}
+	return EventTypeUnknown
+}
+
+// MarshalJSON encodes the event type by its stable name
+func (e GameEventType) MarshalJSON() ([]byte, error) {
+	if info, ok := eventTypes[e]; ok {
+		return json.Marshal(info.name)
+	}
+	return json.Marshal(int(e))
+}
+
+// UnmarshalJSON decodes the event type from either its stable name, or its legacy number
+func (e *GameEventType) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		*e = ParseGameEventType(name)
+		return nil
+	}
+
+	var number int
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*e = GameEventType(number)
+	return nil
+}
+
+// MarshalCBOR encodes the event type by its stable name
+func (e GameEventType) MarshalCBOR() ([]byte, error) {
+	if info, ok := eventTypes[e]; ok {
+		return cbor.Marshal(info.name)
+	}
+	return cbor.Marshal(int(e))
+}
+
+// UnmarshalCBOR decodes the event type from either its stable name, or its legacy number
+func (e *GameEventType) UnmarshalCBOR(data []byte) error {
+	// CBOR text strings are of major type 3
+	if len(data) > 0 && data[0]>>5 == 3 {
+		var name string
+		if err := cbor.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		*e = ParseGameEventType(name)
+		return nil
+	}
+
+	var number int
+	if err := cbor.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*e = GameEventType(number)
+	return nil
+}
+
 func (e GameEventType) String() string {
 	switch e {
 	case EventTypeUserJoinLeave:
@@ -74,6 +237,26 @@ func (e GameEventType) String() string {
 		return "DrawSelectThickness"
 	case EventTypeDrawTempStop:
 		return "DrawTempStopEvent"
+	case EventTypeUpdateSettings:
+		return "UpdateSettingsEvent"
+	case EventTypeRoomPasscode:
+		return "RoomPasscodeEvent"
+	case EventTypeKickPlayer:
+		return "KickPlayerEvent"
+	case EventTypeBanPlayer:
+		return "BanPlayerEvent"
+	case EventTypeRoomLeader:
+		return "RoomLeaderEvent"
+	case EventTypeClockSync:
+		return "ClockSyncEvent"
+	case EventTypeAck:
+		return "AckEvent"
+	case EventTypeWelcome:
+		return "WelcomeEvent"
+	case EventTypeCustomWords:
+		return "CustomWordsEvent"
+	case EventTypeDrawSnapshot:
+		return "DrawSnapshotEvent"
 	case MultiPartPayload:
 		return "MULTI_PART_PAYLOAD"
 	default:
@@ -82,6 +265,15 @@ func (e GameEventType) String() string {
 }
 
 type GameEvent struct {
-	Type GameEventType   `json:"type"`
+	Type GameEventType `json:"type"`
+	// Data is the event's payload. The payload of an event read from a connection is kept in the encoding of the
+	// connection's codec, until it is decoded by the event's listener.
 	Data json.RawMessage `json:"data"`
+
+	// Seq numbers the events sent by the server within a room, and is omitted for events that are not replayed to
+	// reconnecting users
+	Seq uint64 `json:"seq,omitempty"`
+
+	// ID is an optional ID supplied by the client, which is echoed back in the AckEvent for the client's event
+	ID string `json:"id,omitempty"`
 }
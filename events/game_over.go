@@ -1,25 +1,13 @@
 package events
 
 import (
-	"encoding/json"
-
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type GameOverEvent struct {
 	Winners []model.Winner `json:"winners"`
 }
 
-func (e GameOverEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e GameOverEvent) GameEventType() GameEventType {
 	return EventTypeGameOver
 }
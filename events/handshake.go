@@ -0,0 +1,106 @@
+package events
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Protocol versions. Clients which connect without a handshake use the legacy protocol.
+const (
+	// ProtocolVersionLegacy sends event types by their numbers, and does not send a WelcomeEvent
+	ProtocolVersionLegacy = 1
+	// ProtocolVersion sends event types by their stable names, and greets clients with a WelcomeEvent
+	ProtocolVersion = 2
+)
+
+// Capabilities which clients may declare in their handshake
+const (
+	// CapabilityMultiPart declares that the client can reassemble multi-part payloads, without which large events are
+	// always sent as a single message
+	CapabilityMultiPart = "multipart"
+)
+
+var supportedCapabilities = []string{CapabilityMultiPart}
+
+// Handshake is the protocol version and capabilities declared by a client when connecting, through the "v" and "caps"
+// query parameters of the WebSocket URL, e.g. ?v=2&caps=multipart
+type Handshake struct {
+	Version int
+	// Capabilities are the capabilities declared by the client which the server supports
+	Capabilities []string
+}
+
+// ParseHandshake reads a client's handshake from the query parameters of its connection, falling back to the legacy
+// protocol if the client did not declare a version. Unsupported capabilities are ignored.
+func ParseHandshake(query url.Values) (Handshake, error) {
+	version := ProtocolVersionLegacy
+	if v := query.Get("v"); v != "" {
+		var err error
+		version, err = strconv.Atoi(v)
+		if err != nil || version < ProtocolVersionLegacy || version > ProtocolVersion {
+			return Handshake{}, errors.New("unsupported protocol version: " + v)
+		}
+	}
+
+	var capabilities []string
+	for _, capability := range strings.Split(query.Get("caps"), ",") {
+		capability = strings.TrimSpace(capability)
+		for _, supported := range supportedCapabilities {
+			if capability == supported {
+				capabilities = append(capabilities, capability)
+				break
+			}
+		}
+	}
+
+	return Handshake{
+		Version:      version,
+		Capabilities: capabilities,
+	}, nil
+}
+
+// Has checks whether the client declared a capability
+func (h Handshake) Has(capability string) bool {
+	for _, c := range h.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// LegacyEventTypes checks whether the client sends and receives event types by their numbers
+func (h Handshake) LegacyEventTypes() bool {
+	return h.Version < ProtocolVersion
+}
+
+// WelcomeEvent is the first event sent to clients which connect with a handshake, confirming the negotiated protocol
+//
+// To client:
+// - Version is the negotiated protocol version
+// - Capabilities are the client's capabilities which the server supports
+// - Codec is the negotiated WebSocket subprotocol
+type WelcomeEvent struct {
+	Version      int      `json:"version"`
+	Capabilities []string `json:"capabilities"`
+	Codec        string   `json:"codec"`
+}
+
+func (e WelcomeEvent) GameEventType() GameEventType {
+	return EventTypeWelcome
+}
+
+// Welcome confirms a client's handshake
+func Welcome(handshake Handshake, subprotocol string) WelcomeEvent {
+	capabilities := handshake.Capabilities
+	if capabilities == nil {
+		capabilities = []string{}
+	}
+	return WelcomeEvent{
+		Version:      handshake.Version,
+		Capabilities: capabilities,
+		Codec:        subprotocol,
+	}
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// KickPlayerEvent is the client-sourced event in which the room leader removes a player from the room. The kicked
+// player is free to rejoin the room.
+type KickPlayerEvent struct {
+	User model.User `json:"user"`
+}
+
+// BanPlayerEvent is the client-sourced event in which the room leader removes a player from the room, and prevents
+// them from rejoining it.
+type BanPlayerEvent struct {
+	User model.User `json:"user"`
+}
+
+func (e KickPlayerEvent) GameEventType() GameEventType {
+	return EventTypeKickPlayer
+}
+
+func (e KickPlayerEvent) Validate() error {
+	return validateTarget(e.User)
+}
+
+func (e BanPlayerEvent) GameEventType() GameEventType {
+	return EventTypeBanPlayer
+}
+
+func (e BanPlayerEvent) Validate() error {
+	return validateTarget(e.User)
+}
+
+// validateTarget checks that an event targeting another user names the user's ID
+func validateTarget(target model.User) error {
+	if target.ID == "" {
+		return Reject(ErrorInvalidEvent, "Missing the target user's ID")
+	}
+	return nil
+}
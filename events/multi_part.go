@@ -0,0 +1,39 @@
+package events
+
+// MultiPartPayloadEvent is one chunk of a serialized GameEvent which is too large to be sent as a single WebSocket
+// message. The chunks of a payload share the same PayloadID, and the GameEvent is reassembled by concatenating the
+// data of parts 0 to Total-1 in order.
+//
+// Both the client and the server may split events into multi-part payloads, although the server only does so for
+// clients which declare the multipart capability in their handshake. A payload ID only needs to be unique among the
+// payloads being sent over the same connection.
+type MultiPartPayloadEvent struct {
+	PayloadID string `json:"payloadId"`
+	Part      int    `json:"part"`
+	Total     int    `json:"total"`
+	// Data is encoded as base64, since chunks may split multi-byte UTF-8 characters
+	Data []byte `json:"data"`
+}
+
+func (e MultiPartPayloadEvent) GameEventType() GameEventType {
+	return MultiPartPayload
+}
+
+// SplitPayload splits a serialized GameEvent into parts carrying at most partSize bytes of the payload each
+func SplitPayload(payloadID string, payload []byte, partSize int) []MultiPartPayloadEvent {
+	total := (len(payload) + partSize - 1) / partSize
+	parts := make([]MultiPartPayloadEvent, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * partSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		parts = append(parts, MultiPartPayloadEvent{
+			PayloadID: payloadID,
+			Part:      i,
+			Total:     total,
+			Data:      payload[i*partSize : end],
+		})
+	}
+	return parts
+}
@@ -1,10 +1,7 @@
 package events
 
 import (
-	"encoding/json"
-
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type RehydrateEvent struct {
@@ -16,15 +13,6 @@ type RehydrateEvent struct {
 	Lines           []model.Line           `json:"lines"`
 }
 
-func (e RehydrateEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e RehydrateEvent) GameEventType() GameEventType {
 	return EventTypeRehydrate
 }
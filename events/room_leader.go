@@ -0,0 +1,19 @@
+package events
+
+import (
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// RoomLeaderEvent is sent by the room leader to hand off leadership to another connected user, and is broadcasted to
+// all players whenever the room leader changes, whether by hand-off or by succession after the room leader has left
+type RoomLeaderEvent struct {
+	User model.User `json:"user"`
+}
+
+func (e RoomLeaderEvent) GameEventType() GameEventType {
+	return EventTypeRoomLeader
+}
+
+func (e RoomLeaderEvent) Validate() error {
+	return validateTarget(e.User)
+}
@@ -0,0 +1,17 @@
+package events
+
+import (
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// RoomPasscodeEvent is sent by the room leader to change or clear (with an empty passcode) the room's passcode.
+// The event is broadcasted back to all players without the passcode itself.
+type RoomPasscodeEvent struct {
+	User        model.User `json:"user"`
+	Passcode    string     `json:"passcode,omitempty"`
+	HasPasscode bool       `json:"hasPasscode"`
+}
+
+func (e RoomPasscodeEvent) GameEventType() GameEventType {
+	return EventTypeRoomPasscode
+}
@@ -0,0 +1,233 @@
+package events
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	schemaOnce sync.Once
+	schema     map[string]interface{}
+)
+
+// Schema returns a JSON Schema document describing the GameEvent envelope of every event type, for client developers.
+// Each event type is defined under its stable name in $defs, which also defines the payload types by their Go names.
+//
+// The schema describes the shape of events, while the rules which cannot be expressed by a schema are enforced by the
+// events' Validate methods. The non-standard "x-source" keyword tells whether an event is sent by the "server", the
+// "client" or both, and "x-legacyType" is the event type's number in the legacy protocol. Fields are required when
+// the server always sends them, although the server accepts client events with missing fields, which default to their
+// zero values.
+func Schema() map[string]interface{} {
+	schemaOnce.Do(func() {
+		schema = generateSchema()
+	})
+	return schema
+}
+
+var (
+	gameEventTypeType = reflect.TypeOf(GameEventType(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaGenerator collects the definitions of the payload types referenced by events
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+}
+
+func generateSchema() map[string]interface{} {
+	g := &schemaGenerator{
+		defs:  make(map[string]interface{}),
+		names: make(map[reflect.Type]string),
+	}
+
+	eventTypeNames := make([]string, 0, len(eventTypes))
+	for _, info := range eventTypes {
+		eventTypeNames = append(eventTypeNames, info.name)
+	}
+	sort.Strings(eventTypeNames)
+
+	envelopes := make([]interface{}, 0, len(eventTypeNames))
+	for _, name := range eventTypeNames {
+		eventType := eventTypesByName[name]
+		info := eventTypes[eventType]
+		g.defs[name] = map[string]interface{}{
+			"type":         "object",
+			"x-source":     info.source.String(),
+			"x-legacyType": int(eventType),
+			"properties": map[string]interface{}{
+				"type": map[string]interface{}{"const": name},
+				"data": g.typeSchema(reflect.TypeOf(info.prototype)),
+				"seq": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"description": "Numbers the events sent by the server within a room",
+				},
+				"id": map[string]interface{}{
+					"type":        "string",
+					"description": "Correlates a client's event with the server's acknowledgement",
+				},
+			},
+			"required": []string{"type", "data"},
+		}
+		envelopes = append(envelopes, ref(name))
+	}
+
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDraft,
+		"title":       "GameEvent",
+		"description": "An event sent over a room's WebSocket connection",
+		"oneOf":       envelopes,
+		"$defs":       g.defs,
+	}
+}
+
+func (s Source) String() string {
+	switch s {
+	case ClientSourced:
+		return "client"
+	case BiDirectional:
+		return "both"
+	default:
+		return "server"
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == gameEventTypeType:
+		names := make([]string, 0, len(eventTypes))
+		for _, info := range eventTypes {
+			names = append(names, info.name)
+		}
+		sort.Strings(names)
+		return map[string]interface{}{"type": "string", "enum": names}
+	case t == rawMessageType, t.Implements(jsonMarshalerType):
+		// Values which define their own JSON encoding may have any shape
+		return map[string]interface{}{}
+	case t.Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+		}
+		// Nil slices are encoded as null
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": g.typeSchema(t.Elem())}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    g.typeSchema(t.Elem()),
+			"minItems": t.Len(),
+			"maxItems": t.Len(),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": g.typeSchema(t.Elem()),
+		}
+	case reflect.Ptr:
+		return map[string]interface{}{
+			"anyOf": []interface{}{g.typeSchema(t.Elem()), map[string]interface{}{"type": "null"}},
+		}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return ref(g.define(t))
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// define adds a named struct type to the definitions, returning its name. Types with the same name from different
+// packages are qualified by their package.
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = strings.ReplaceAll(t.String(), ".", "_")
+	}
+	g.names[t] = name
+	// Reserve the name before generating the struct's schema, in case the struct refers to itself
+	g.defs[name] = nil
+	g.defs[name] = g.structSchema(t)
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	g.addFields(t, properties, &required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// addFields adds the fields of a struct as they are encoded by encoding/json, promoting the fields of untagged
+// embedded structs
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, options = tag[:comma], tag[comma+1:]
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// Unexported field
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = g.typeSchema(field.Type)
+		if !strings.Contains(","+options+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -6,24 +6,72 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// SerializableEvent is an event which can be sent to clients. Events are serialized by the codec of each connection,
+// which encodes the event's exported fields named by their JSON struct tags.
 type SerializableEvent interface {
-	RawJSON() json.RawMessage
 	GameEventType() GameEventType
 }
 
-func ToJson(event SerializableEvent) []byte {
-	rawEventData := event.RawJSON()
+// CoalescableEvent is an event that supersedes any previous event with the same coalesce key that is still waiting to be
+// sent to a user, such as countdowns. An empty coalesce key means the event cannot be coalesced.
+type CoalescableEvent interface {
+	SerializableEvent
+	CoalesceKey() string
+}
+
+// CoalesceKey returns the coalesce key of an event numbered with the given sequence number, or an empty string if the
+// event cannot be coalesced. Sequenced events are never coalesced, since a dropped event would leave a gap in the
+// sequence received by the user.
+func CoalesceKey(event SerializableEvent, seq uint64) string {
+	if seq != 0 {
+		return ""
+	}
+	if coalescable, ok := event.(CoalescableEvent); ok {
+		return coalescable.CoalesceKey()
+	}
+	return ""
+}
+
+// legacyGameEvent is the GameEvent envelope of the legacy protocol, which sends the event type by its number
+type legacyGameEvent struct {
+	Type int             `json:"type"`
+	Data json.RawMessage `json:"data"`
+	Seq  uint64          `json:"seq,omitempty"`
+}
+
+// ToJson serializes an event into a JSON GameEvent envelope, numbered with the given sequence number
+func ToJson(event SerializableEvent, seq uint64) []byte {
+	return toJson(event, seq, false)
+}
+
+// ToLegacyJson serializes an event into a JSON GameEvent envelope of the legacy protocol. Event types nested in the
+// event itself are still sent by their names, as no such events existed in the legacy protocol.
+func ToLegacyJson(event SerializableEvent, seq uint64) []byte {
+	return toJson(event, seq, true)
+}
+
+func toJson(event SerializableEvent, seq uint64, legacy bool) []byte {
 	eventType := event.GameEventType()
-	if rawEventData == nil {
+	rawEventData, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not marshal " + eventType.String() + " into JSON")
 		return nil
 	}
 
-	gameEvent := GameEvent{
+	var envelope interface{} = &GameEvent{
 		Type: eventType,
 		Data: rawEventData,
+		Seq:  seq,
+	}
+	if legacy {
+		envelope = &legacyGameEvent{
+			Type: int(eventType),
+			Data: rawEventData,
+			Seq:  seq,
+		}
 	}
 
-	bytes, err := json.Marshal(&gameEvent)
+	bytes, err := json.Marshal(envelope)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not marshal GameEvent<" + eventType.String() + "> into JSON")
 		return nil
@@ -1,10 +1,9 @@
 package events
 
 import (
-	"encoding/json"
+	"time"
 
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type TurnDrawingNonce struct {
@@ -21,30 +20,37 @@ type TurnDrawingNonce struct {
 //
 // To rest of players:
 // - Word is nil
+//
+// Updates without a nonce are only sent when the deadline changes, or when a new hint is given.
+//
+// Paused is true while the countdown is paused, waiting for a disconnected drawer to reconnect, in which case the
+// deadline is omitted
 type TurnDrawingEvent struct {
 	Nonce    *TurnDrawingNonce `json:"nonce,omitempty"`
 	Hints    []model.Hint      `json:"hints,omitempty"`
 	MaxTime  int               `json:"maxTime"`
 	TimeLeft int               `json:"timeLeft"`
+	Deadline int64             `json:"deadline,omitempty"`
 	Status   model.TurnStatus  `json:"status"`
-}
-
-func (e TurnDrawingEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
+	Paused   bool              `json:"paused,omitempty"`
 }
 
 func (e TurnDrawingEvent) GameEventType() GameEventType {
 	return EventTypeTurnDrawing
 }
 
+// CoalesceKey allows updates (events without a nonce) to supersede each other
+func (e TurnDrawingEvent) CoalesceKey() string {
+	if e.Nonce != nil {
+		return ""
+	}
+	return e.GameEventType().String()
+}
+
 func turnBeginDrawing(
 	currentTurnUser model.User,
 	maxTimeSeconds int,
+	deadline time.Time,
 	wordLengths []int,
 	word string,
 ) TurnDrawingEvent {
@@ -62,29 +68,53 @@ func turnBeginDrawing(
 		Hints:    nil,
 		MaxTime:  maxTimeSeconds,
 		TimeLeft: maxTimeSeconds,
+		Deadline: UnixMillis(deadline),
 		Status:   model.TurnDrawing,
 	}
 }
 
-func TurnBeginDrawing(currentTurnUser model.User, maxTimeSeconds int, wordLengths []int) TurnDrawingEvent {
-	return turnBeginDrawing(currentTurnUser, maxTimeSeconds, wordLengths, "")
+func TurnBeginDrawing(
+	currentTurnUser model.User,
+	maxTimeSeconds int,
+	deadline time.Time,
+	wordLengths []int,
+) TurnDrawingEvent {
+	return turnBeginDrawing(currentTurnUser, maxTimeSeconds, deadline, wordLengths, "")
 }
 
 func TurnBeginDrawingCurrentPlayer(
 	currentTurnUser model.User,
 	maxTimeSeconds int,
+	deadline time.Time,
 	wordLengths []int,
 	word string,
 ) TurnDrawingEvent {
-	return turnBeginDrawing(currentTurnUser, maxTimeSeconds, wordLengths, word)
+	return turnBeginDrawing(currentTurnUser, maxTimeSeconds, deadline, wordLengths, word)
+}
+
+func TurnDrawingUpdate(
+	maxTimeSeconds int,
+	timeLeftSeconds int,
+	deadline time.Time,
+	hints []model.Hint,
+) TurnDrawingEvent {
+	return TurnDrawingEvent{
+		Nonce:    nil,
+		Hints:    hints,
+		MaxTime:  maxTimeSeconds,
+		TimeLeft: timeLeftSeconds,
+		Deadline: UnixMillis(deadline),
+		Status:   model.TurnDrawing,
+	}
 }
 
-func TurnDrawingCountdown(maxTimeSeconds, timeLeftSeconds int, hints []model.Hint) TurnDrawingEvent {
+func TurnDrawingPaused(maxTimeSeconds, timeLeftSeconds int, hints []model.Hint) TurnDrawingEvent {
 	return TurnDrawingEvent{
 		Nonce:    nil,
 		Hints:    hints,
 		MaxTime:  maxTimeSeconds,
 		TimeLeft: timeLeftSeconds,
 		Status:   model.TurnDrawing,
+		Paused:   true,
 	}
 }
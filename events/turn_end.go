@@ -1,15 +1,27 @@
 package events
 
 import (
-	"encoding/json"
+	"time"
 
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
+)
+
+// TurnEndReason describes why a drawing turn ended
+type TurnEndReason int
+
+const (
+	// TurnEndTimeout means the drawing time ran out
+	TurnEndTimeout TurnEndReason = iota
+	// TurnEndAllGuessed means every player guessed the word
+	TurnEndAllGuessed
+	// TurnEndDrawerLeft means the drawer left the room, or did not reconnect within the grace period
+	TurnEndDrawerLeft
 )
 
 type TurnEndNonce struct {
-	User   model.User `json:"user"`
-	Answer string     `json:"answer"`
+	User   model.User    `json:"user"`
+	Answer string        `json:"answer"`
+	Reason TurnEndReason `json:"reason"`
 }
 
 // TurnEndEvent is the server-sourced event that notifies all players that the current turn has ended and a new turn
@@ -20,39 +32,30 @@ type TurnEndEvent struct {
 	Nonce    *TurnEndNonce    `json:"nonce,omitempty"`
 	MaxTime  int              `json:"maxTime"`
 	TimeLeft int              `json:"timeLeft"`
+	Deadline int64            `json:"deadline,omitempty"`
 	Status   model.TurnStatus `json:"status"`
 }
 
-func (e TurnEndEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e TurnEndEvent) GameEventType() GameEventType {
 	return EventTypeTurnEnd
 }
 
-func TurnBeginEnd(userModel model.User, word string, maxTimeSeconds int) TurnEndEvent {
+func TurnBeginEnd(
+	userModel model.User,
+	word string,
+	maxTimeSeconds int,
+	deadline time.Time,
+	reason TurnEndReason,
+) TurnEndEvent {
 	return TurnEndEvent{
 		Nonce: &TurnEndNonce{
 			User:   userModel,
 			Answer: word,
+			Reason: reason,
 		},
 		MaxTime:  maxTimeSeconds,
 		TimeLeft: maxTimeSeconds,
-		Status:   model.TurnEnded,
-	}
-}
-
-func TurnEndCountdown(maxTimeSeconds int, timeLeftSeconds int) TurnEndEvent {
-	return TurnEndEvent{
-		Nonce:    nil,
-		MaxTime:  maxTimeSeconds,
-		TimeLeft: timeLeftSeconds,
+		Deadline: UnixMillis(deadline),
 		Status:   model.TurnEnded,
 	}
 }
@@ -1,10 +1,9 @@
 package events
 
 import (
-	"encoding/json"
+	"time"
 
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type TurnNextPlayerNonce struct {
@@ -16,23 +15,15 @@ type TurnNextPlayerEvent struct {
 	Nonce    *TurnNextPlayerNonce `json:"nonce,omitempty"`
 	MaxTime  int                  `json:"maxTime"`
 	TimeLeft int                  `json:"timeLeft"`
+	Deadline int64                `json:"deadline,omitempty"`
 	Status   model.TurnStatus     `json:"status"`
 }
 
-func (e TurnNextPlayerEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e TurnNextPlayerEvent) GameEventType() GameEventType {
 	return EventTypeTurnNextPlayer
 }
 
-func TurnBeginNextPlayer(nextPlayer model.User, round int, maxTimeSeconds int) TurnNextPlayerEvent {
+func TurnBeginNextPlayer(nextPlayer model.User, round int, maxTimeSeconds int, deadline time.Time) TurnNextPlayerEvent {
 	return TurnNextPlayerEvent{
 		Nonce: &TurnNextPlayerNonce{
 			NextTurnUser: nextPlayer,
@@ -40,15 +31,7 @@ func TurnBeginNextPlayer(nextPlayer model.User, round int, maxTimeSeconds int) T
 		},
 		MaxTime:  maxTimeSeconds,
 		TimeLeft: maxTimeSeconds,
-		Status:   model.TurnNextPlayer,
-	}
-}
-
-func TurnNextPlayerCountdown(maxTimeSeconds int, timeLeftSeconds int) TurnNextPlayerEvent {
-	return TurnNextPlayerEvent{
-		Nonce:    nil,
-		MaxTime:  maxTimeSeconds,
-		TimeLeft: timeLeftSeconds,
+		Deadline: UnixMillis(deadline),
 		Status:   model.TurnNextPlayer,
 	}
 }
@@ -10,3 +10,14 @@ type TurnWordSelectedEvent struct {
 	User  model.User `json:"user"`
 	Index int        `json:"index"`
 }
+
+func (e TurnWordSelectedEvent) GameEventType() GameEventType {
+	return EventTypeTurnWordSelected
+}
+
+func (e TurnWordSelectedEvent) Validate() error {
+	if e.Index < 0 {
+		return Reject(ErrorInvalidWordIndex, "Word selection index must not be negative")
+	}
+	return nil
+}
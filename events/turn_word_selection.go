@@ -1,10 +1,9 @@
 package events
 
 import (
-	"encoding/json"
+	"time"
 
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type TurnWordSelectionNonce struct {
@@ -25,23 +24,20 @@ type TurnWordSelectionEvent struct {
 	Nonce    *TurnWordSelectionNonce `json:"nonce,omitempty"`
 	MaxTime  int                     `json:"maxTime"`
 	TimeLeft int                     `json:"timeLeft"`
+	Deadline int64                   `json:"deadline,omitempty"`
 	Status   model.TurnStatus        `json:"status"`
 }
 
-func (e TurnWordSelectionEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e TurnWordSelectionEvent) GameEventType() GameEventType {
 	return EventTypeTurnWordSelection
 }
 
-func turnBeginSelection(currentTurnUser model.User, maxTimeSeconds int, words []string) TurnWordSelectionEvent {
+func turnBeginSelection(
+	currentTurnUser model.User,
+	maxTimeSeconds int,
+	deadline time.Time,
+	words []string,
+) TurnWordSelectionEvent {
 	return TurnWordSelectionEvent{
 		Nonce: &TurnWordSelectionNonce{
 			User:  currentTurnUser,
@@ -49,27 +45,20 @@ func turnBeginSelection(currentTurnUser model.User, maxTimeSeconds int, words []
 		},
 		MaxTime:  maxTimeSeconds,
 		TimeLeft: maxTimeSeconds,
+		Deadline: UnixMillis(deadline),
 		Status:   model.TurnSelection,
 	}
 }
 
-func TurnBeginSelection(currentTurnUser model.User, maxTimeSeconds int) TurnWordSelectionEvent {
-	return turnBeginSelection(currentTurnUser, maxTimeSeconds, nil)
+func TurnBeginSelection(currentTurnUser model.User, maxTimeSeconds int, deadline time.Time) TurnWordSelectionEvent {
+	return turnBeginSelection(currentTurnUser, maxTimeSeconds, deadline, nil)
 }
 
 func TurnBeginSelectionCurrentPlayer(
 	currentTurnUser model.User,
 	maxTimeSeconds int,
+	deadline time.Time,
 	words []string,
 ) TurnWordSelectionEvent {
-	return turnBeginSelection(currentTurnUser, maxTimeSeconds, words)
-}
-
-func TurnWordSelectionCountdown(maxTimeSeconds, timeLeftSeconds int) TurnWordSelectionEvent {
-	return TurnWordSelectionEvent{
-		Nonce:    nil,
-		MaxTime:  maxTimeSeconds,
-		TimeLeft: timeLeftSeconds,
-		Status:   model.TurnSelection,
-	}
+	return turnBeginSelection(currentTurnUser, maxTimeSeconds, deadline, words)
 }
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"github.com/kvnxiao/pictorio/game/settings"
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// UpdateSettingsEvent is sent by the room leader to change the settings of the room before a game has started, and is
+// broadcasted back to all players with the new settings once they have been validated and applied
+type UpdateSettingsEvent struct {
+	User     model.User            `json:"user"`
+	Settings settings.GameSettings `json:"settings"`
+}
+
+func (e UpdateSettingsEvent) GameEventType() GameEventType {
+	return EventTypeUpdateSettings
+}
+
+func (e UpdateSettingsEvent) Validate() error {
+	if err := e.Settings.Validate(); err != nil {
+		return Reject(ErrorInvalidSettings, err.Error())
+	}
+	return nil
+}
@@ -1,10 +1,7 @@
 package events
 
 import (
-	"encoding/json"
-
 	"github.com/kvnxiao/pictorio/model"
-	"github.com/rs/zerolog/log"
 )
 
 type UserJoinLeaveAction int
@@ -12,6 +9,8 @@ type UserJoinLeaveAction int
 const (
 	UserActionJoin UserJoinLeaveAction = iota
 	UserActionLeave
+	UserActionKicked
+	UserActionBanned
 )
 
 type UserJoinLeaveEvent struct {
@@ -19,15 +18,6 @@ type UserJoinLeaveEvent struct {
 	Action      UserJoinLeaveAction `json:"action"`
 }
 
-func (e UserJoinLeaveEvent) RawJSON() json.RawMessage {
-	eventBytes, err := json.Marshal(e)
-	if err != nil {
-		log.Error().Err(err).Msg("Could not marshal " + e.GameEventType().String() + " into JSON.")
-		return nil
-	}
-	return eventBytes
-}
-
 func (e UserJoinLeaveEvent) GameEventType() GameEventType {
 	return EventTypeUserJoinLeave
 }
@@ -46,3 +36,11 @@ func UserJoin(playerState model.PlayerState) UserJoinLeaveEvent {
 func UserLeave(playerState model.PlayerState) UserJoinLeaveEvent {
 	return joinLeaveEvent(playerState, UserActionLeave)
 }
+
+func UserKicked(playerState model.PlayerState) UserJoinLeaveEvent {
+	return joinLeaveEvent(playerState, UserActionKicked)
+}
+
+func UserBanned(playerState model.PlayerState) UserJoinLeaveEvent {
+	return joinLeaveEvent(playerState, UserActionBanned)
+}
@@ -0,0 +1,8 @@
+package events
+
+// Validator is implemented by client-sourced events which check their own payload, independently of the state of the
+// room. Events are validated after being decoded, and rejected before being handled if invalid.
+type Validator interface {
+	// Validate returns an *ActionError describing why the event is invalid, or nil if it is valid
+	Validate() error
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+)
+
+// Options configures the game loop of every new room
+type Options struct {
+	// Clock drives the timers of every room, which may be a scaled clock to run games faster than real time
+	Clock clock.Clock
+	// Seed seeds every room's source of randomness to reproduce games. Each room is given its own random seed if zero.
+	Seed int64
+}
+
+var (
+	optionsMu sync.RWMutex
+	options   = Options{
+		Clock: clock.Real(),
+	}
+)
+
+// Configure sets the options used for new rooms. A nil clock falls back to the real clock.
+func Configure(opts Options) {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+
+	options = opts
+}
+
+// roomOptions returns the configured clock, and the seed for a new room
+func roomOptions() (clock.Clock, int64) {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return options.Clock, seed
+}
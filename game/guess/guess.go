@@ -4,10 +4,8 @@ import (
 	"github.com/kvnxiao/pictorio/model"
 )
 
-// PlayerGuesses represents the state of guesses for a single drawing turn (the drawer, and the rest of the players).
-// The first player to guess correctly is awarded the most points, and the drawer is awarded some points (slightly less
-// than the first guesser), while the rest of the players will be awarded less points if guessing correctly after the
-// first guess has been established
+// PlayerGuesses represents the state of guesses for a single drawing turn (the drawer, and the rest of the players),
+// which ranks the players in the order that they guess the word for a Scorer to award points by
 type PlayerGuesses struct {
 	guessesRemaining map[string]struct{}
 	maxGuesses       int
@@ -37,10 +35,14 @@ func (g *PlayerGuesses) HasGuessed(playerID string) bool {
 	return !hasNotGuessed
 }
 
-func (g *PlayerGuesses) AddGuessed(playerID string) (guesserPoints int, drawerPoints int) {
+// Guessers returns the number of players guessing the word, which excludes the drawer
+func (g *PlayerGuesses) Guessers() int {
+	return g.maxGuesses
+}
+
+// AddGuessed records that a player has guessed the word, and returns their rank, starting from 1 for the first player
+// to guess the word
+func (g *PlayerGuesses) AddGuessed(playerID string) (rank int) {
 	delete(g.guessesRemaining, playerID)
-	if len(g.guessesRemaining) == g.maxGuesses-1 {
-		return 3, 2
-	}
-	return 1, 0
+	return g.maxGuesses - len(g.guessesRemaining)
 }
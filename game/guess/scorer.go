@@ -0,0 +1,140 @@
+package guess
+
+import (
+	"time"
+
+	"github.com/kvnxiao/pictorio/game/settings"
+	"github.com/kvnxiao/pictorio/model"
+	"github.com/kvnxiao/pictorio/words"
+)
+
+// Guess describes a correct guess of the word being drawn, for a Scorer to award points for
+type Guess struct {
+	// Rank is the order in which the player guessed the word, starting from 1 for the first player to guess it
+	Rank int
+	// Guessers is the number of players guessing the word, which excludes the drawer
+	Guessers int
+	// TimeLeft is the time that was left to guess the word, out of the TotalTime of the drawing phase
+	TimeLeft  time.Duration
+	TotalTime time.Duration
+	// Difficulty is the difficulty of the word
+	Difficulty words.Difficulty
+}
+
+// Award is the points awarded to the guesser and the drawer for a correct guess, which are the sums of the points in
+// the breakdown
+type Award struct {
+	GuesserPoints int
+	DrawerPoints  int
+	Breakdown     []model.PointsBreakdown
+}
+
+func (a *Award) add(reason string, guesserPoints int, drawerPoints int) {
+	if guesserPoints == 0 && drawerPoints == 0 {
+		return
+	}
+	a.GuesserPoints += guesserPoints
+	a.DrawerPoints += drawerPoints
+	a.Breakdown = append(a.Breakdown, model.PointsBreakdown{
+		Reason:        reason,
+		GuesserPoints: guesserPoints,
+		DrawerPoints:  drawerPoints,
+	})
+}
+
+// Scorer awards points for correct guesses
+type Scorer interface {
+	Score(guess Guess) Award
+}
+
+// NewScorer returns the scorer for one of the scoring rules of the room settings, falling back to the classic rules
+func NewScorer(scoring string) Scorer {
+	switch scoring {
+	case settings.ScoringTimeDecay:
+		return timeDecayScorer{}
+	case settings.ScoringRankLadder:
+		return rankLadderScorer{}
+	case settings.ScoringDrawerPerGuesser:
+		return drawerPerGuesserScorer{}
+	default:
+		return classicScorer{}
+	}
+}
+
+// difficultyBonus is the extra points that a guesser is awarded for guessing harder words
+func difficultyBonus(difficulty words.Difficulty) int {
+	switch difficulty {
+	case words.Hard:
+		return 2
+	case words.Medium:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// classicScorer awards 3 points to the first guesser and 2 points to the drawer, then 1 point to every other guesser
+type classicScorer struct{}
+
+func (classicScorer) Score(guess Guess) Award {
+	var award Award
+	if guess.Rank == 1 {
+		award.add("first_guess", 3, 2)
+	} else {
+		award.add("guess", 1, 0)
+	}
+	return award
+}
+
+// maxTimeDecayPoints is the most points a guesser can be awarded for the time left by the timeDecayScorer
+const maxTimeDecayPoints = 10
+
+// timeDecayScorer awards guessers between 1 and 10 points in proportion to the time that was left to guess the word,
+// plus a bonus for harder words. The drawer is awarded half of the points for the time left.
+type timeDecayScorer struct{}
+
+func (timeDecayScorer) Score(guess Guess) Award {
+	var award Award
+	points := 1
+	if guess.TotalTime > 0 && guess.TimeLeft > 0 {
+		fraction := float64(guess.TimeLeft) / float64(guess.TotalTime)
+		if fraction > 1 {
+			fraction = 1
+		}
+		points += int(fraction*float64(maxTimeDecayPoints-1) + 0.5)
+	}
+	award.add("time_left", points, points/2)
+	award.add("difficulty", difficultyBonus(guess.Difficulty), 0)
+	return award
+}
+
+// rankLadderScorer awards the last guesser 2 points, and every guesser before them 1 more point than the next, so
+// that the first guesser of a room with more players is awarded more points. It also awards a bonus for harder words,
+// and 1 point to the drawer for every guess.
+type rankLadderScorer struct{}
+
+func (rankLadderScorer) Score(guess Guess) Award {
+	var award Award
+	points := guess.Guessers - guess.Rank + 2
+	if points < 2 {
+		points = 2
+	}
+	award.add("rank", points, 1)
+	award.add("difficulty", difficultyBonus(guess.Difficulty), 0)
+	return award
+}
+
+// drawerPerGuesserScorer awards the drawer 2 points for every player who guesses the word, rewarding drawings that
+// everyone can guess. The first guesser is awarded 3 points, and every other guesser 2 points.
+type drawerPerGuesserScorer struct{}
+
+func (drawerPerGuesserScorer) Score(guess Guess) Award {
+	var award Award
+	if guess.Rank == 1 {
+		award.add("first_guess", 3, 0)
+	} else {
+		award.add("guess", 2, 0)
+	}
+	award.add("guesser_drawn_for", 0, 2)
+	return award
+}
@@ -0,0 +1,199 @@
+package guess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kvnxiao/pictorio/game/settings"
+	"github.com/kvnxiao/pictorio/model"
+	"github.com/kvnxiao/pictorio/words"
+)
+
+const totalTime = 60 * time.Second
+
+func breakdown(reason string, guesserPoints int, drawerPoints int) model.PointsBreakdown {
+	return model.PointsBreakdown{Reason: reason, GuesserPoints: guesserPoints, DrawerPoints: drawerPoints}
+}
+
+func newGuess(rank int, guessers int, timeLeft time.Duration, difficulty words.Difficulty) Guess {
+	return Guess{
+		Rank:       rank,
+		Guessers:   guessers,
+		TimeLeft:   timeLeft,
+		TotalTime:  totalTime,
+		Difficulty: difficulty,
+	}
+}
+
+func TestScorers(t *testing.T) {
+	tests := []struct {
+		name      string
+		scoring   string
+		guess     Guess
+		guesser   int
+		drawer    int
+		breakdown []model.PointsBreakdown
+	}{
+		{
+			name:      "classic first guess",
+			scoring:   settings.ScoringClassic,
+			guess:     newGuess(1, 4, totalTime, words.Easy),
+			guesser:   3,
+			drawer:    2,
+			breakdown: []model.PointsBreakdown{breakdown("first_guess", 3, 2)},
+		},
+		{
+			name:      "classic later guess",
+			scoring:   settings.ScoringClassic,
+			guess:     newGuess(2, 4, totalTime, words.Easy),
+			guesser:   1,
+			breakdown: []model.PointsBreakdown{breakdown("guess", 1, 0)},
+		},
+		{
+			name:      "classic ignores time left and difficulty",
+			scoring:   settings.ScoringClassic,
+			guess:     newGuess(2, 4, 0, words.Hard),
+			guesser:   1,
+			breakdown: []model.PointsBreakdown{breakdown("guess", 1, 0)},
+		},
+		{
+			name:      "time decay with all of the time left",
+			scoring:   settings.ScoringTimeDecay,
+			guess:     newGuess(1, 4, totalTime, words.Easy),
+			guesser:   10,
+			drawer:    5,
+			breakdown: []model.PointsBreakdown{breakdown("time_left", 10, 5)},
+		},
+		{
+			name:    "time decay with half of the time left",
+			scoring: settings.ScoringTimeDecay,
+			guess:   newGuess(1, 4, totalTime/2, words.Medium),
+			guesser: 7,
+			drawer:  3,
+			breakdown: []model.PointsBreakdown{
+				breakdown("time_left", 6, 3),
+				breakdown("difficulty", 1, 0),
+			},
+		},
+		{
+			name:    "time decay with no time left",
+			scoring: settings.ScoringTimeDecay,
+			guess:   newGuess(1, 4, 0, words.Hard),
+			guesser: 3,
+			breakdown: []model.PointsBreakdown{
+				breakdown("time_left", 1, 0),
+				breakdown("difficulty", 2, 0),
+			},
+		},
+		{
+			name:      "time decay caps the time left",
+			scoring:   settings.ScoringTimeDecay,
+			guess:     newGuess(1, 4, 2*totalTime, words.Easy),
+			guesser:   10,
+			drawer:    5,
+			breakdown: []model.PointsBreakdown{breakdown("time_left", 10, 5)},
+		},
+		{
+			name:      "time decay ignores rank",
+			scoring:   settings.ScoringTimeDecay,
+			guess:     newGuess(4, 4, totalTime, words.Easy),
+			guesser:   10,
+			drawer:    5,
+			breakdown: []model.PointsBreakdown{breakdown("time_left", 10, 5)},
+		},
+		{
+			name:      "rank ladder first guess",
+			scoring:   settings.ScoringRankLadder,
+			guess:     newGuess(1, 4, totalTime, words.Easy),
+			guesser:   5,
+			drawer:    1,
+			breakdown: []model.PointsBreakdown{breakdown("rank", 5, 1)},
+		},
+		{
+			name:      "rank ladder first guess with more guessers",
+			scoring:   settings.ScoringRankLadder,
+			guess:     newGuess(1, 8, totalTime, words.Easy),
+			guesser:   9,
+			drawer:    1,
+			breakdown: []model.PointsBreakdown{breakdown("rank", 9, 1)},
+		},
+		{
+			name:      "rank ladder last guess",
+			scoring:   settings.ScoringRankLadder,
+			guess:     newGuess(4, 4, 0, words.Easy),
+			guesser:   2,
+			drawer:    1,
+			breakdown: []model.PointsBreakdown{breakdown("rank", 2, 1)},
+		},
+		{
+			name:    "rank ladder with a hard word",
+			scoring: settings.ScoringRankLadder,
+			guess:   newGuess(2, 4, 0, words.Hard),
+			guesser: 6,
+			drawer:  1,
+			breakdown: []model.PointsBreakdown{
+				breakdown("rank", 4, 1),
+				breakdown("difficulty", 2, 0),
+			},
+		},
+		{
+			name:    "drawer per guesser first guess",
+			scoring: settings.ScoringDrawerPerGuesser,
+			guess:   newGuess(1, 4, totalTime, words.Easy),
+			guesser: 3,
+			drawer:  2,
+			breakdown: []model.PointsBreakdown{
+				breakdown("first_guess", 3, 0),
+				breakdown("guesser_drawn_for", 0, 2),
+			},
+		},
+		{
+			name:    "drawer per guesser later guess",
+			scoring: settings.ScoringDrawerPerGuesser,
+			guess:   newGuess(3, 4, 0, words.Hard),
+			guesser: 2,
+			drawer:  2,
+			breakdown: []model.PointsBreakdown{
+				breakdown("guess", 2, 0),
+				breakdown("guesser_drawn_for", 0, 2),
+			},
+		},
+		{
+			name:      "unknown scoring falls back to classic",
+			scoring:   "unknown",
+			guess:     newGuess(1, 4, totalTime, words.Hard),
+			guesser:   3,
+			drawer:    2,
+			breakdown: []model.PointsBreakdown{breakdown("first_guess", 3, 2)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			award := NewScorer(test.scoring).Score(test.guess)
+			if award.GuesserPoints != test.guesser || award.DrawerPoints != test.drawer {
+				t.Errorf("awarded %d points to the guesser and %d to the drawer, expected %d and %d",
+					award.GuesserPoints, award.DrawerPoints, test.guesser, test.drawer)
+			}
+			if !reflect.DeepEqual(award.Breakdown, test.breakdown) {
+				t.Errorf("breakdown %+v, expected %+v", award.Breakdown, test.breakdown)
+			}
+		})
+	}
+}
+
+func TestScoringRulesDiffer(t *testing.T) {
+	// The second of four guessers, with half of the time left to guess a hard word
+	guess := newGuess(2, 4, totalTime/2, words.Hard)
+	seen := make(map[[2]int]string)
+	for _, scoring := range settings.ScoringRules {
+		award := NewScorer(scoring).Score(guess)
+		points := [2]int{award.GuesserPoints, award.DrawerPoints}
+		if other, ok := seen[points]; ok {
+			t.Errorf("%s and %s both award %d points to the guesser and %d to the drawer",
+				scoring, other, points[0], points[1])
+		}
+		seen[points] = scoring
+	}
+}
@@ -27,8 +27,18 @@ func NewHint(hints []model.Hint, timings []int) *Hint {
 	}
 }
 
+// NextHintTime returns the time left in seconds at which the next hint should be given, or false if there are no
+// hints left to give
+func (h *Hint) NextHintTime() (int, bool) {
+	if h.hintsGiven < h.maxToGive {
+		return h.timings[0], true
+	}
+	return 0, false
+}
+
+// NextHint gives the next hint once the time left has reached the next hint's timing
 func (h *Hint) NextHint(timeLeftSeconds int) (model.Hint, bool) {
-	if h.hintsGiven < h.maxToGive && timeLeftSeconds == h.timings[0] {
+	if h.hintsGiven < h.maxToGive && timeLeftSeconds <= h.timings[0] {
 		h.hintsGiven += 1
 
 		// pop next hint
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/kvnxiao/pictorio/ctxs"
+	"github.com/kvnxiao/pictorio/events"
 	"github.com/kvnxiao/pictorio/game/state"
 	"github.com/kvnxiao/pictorio/game/user"
 	"github.com/kvnxiao/pictorio/model"
@@ -24,6 +27,15 @@ type Room struct {
 	// roomID represents the unique identifier associated with the room
 	roomID string
 
+	// public represents whether the room is listed in the lobby browser
+	public bool
+
+	// createdAt is the time the room was created, used to order rooms in the lobby browser
+	createdAt time.Time
+
+	// seed is the seed of the room's source of randomness, which reproduces the room's games
+	seed int64
+
 	// mu is a mutex for checking the state of the room, i.e. whether it is closed or not when a person joins
 	mu sync.Mutex
 	// userMu is a mutex for handling websocket connections between
@@ -41,24 +53,108 @@ type Room struct {
 	startCleanupChan chan bool
 }
 
+// RoomOptions are the options chosen by the creator of a room
+type RoomOptions struct {
+	// Public lists the room in the lobby browser
+	Public bool
+	// Passcode is required to join the room if non-empty
+	Passcode string
+}
+
 // NewRoom creates an empty room with the provided roomID string and sets up the global.
-func NewRoom(roomID string) *Room {
+func NewRoom(roomID string, opts RoomOptions) *Room {
+	clk, seed := roomOptions()
+
 	room := &Room{
-		roomID:           roomID,
-		closed:           false,
-		usersMap:         make(map[string]*user.User),
-		gameProcessor:    state.NewGameStateProcessor(roomID),
+		roomID:    roomID,
+		public:    opts.Public,
+		createdAt: time.Now(),
+		seed:      seed,
+		closed:    false,
+		usersMap:  make(map[string]*user.User),
+		gameProcessor: state.NewGameStateProcessor(roomID, state.Options{
+			Passcode: opts.Passcode,
+			Clock:    clk,
+			Seed:     seed,
+		}),
 		startCleanupChan: make(chan bool),
 	}
 	go room.gameProcessor.EventProcessor(room.startCleanupChan)
 	return room
 }
 
+// Seed returns the seed of the room's source of randomness, which can be passed to the server to reproduce the room's
+// games.
+func (r *Room) Seed() int64 {
+	return r.seed
+}
+
 // ID returns the unique room ID representing this room.
 func (r *Room) ID() string {
 	return r.roomID
 }
 
+// IsPublic returns whether the room is listed in the lobby browser.
+func (r *Room) IsPublic() bool {
+	return r.public
+}
+
+// CreatedAt returns the time the room was created.
+func (r *Room) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+// RequiresPasscode returns whether a passcode or join token is needed to connect to the room.
+func (r *Room) RequiresPasscode() bool {
+	return r.gameProcessor.Access().RequiresPasscode()
+}
+
+// VerifyPasscode checks the passcode tried by a client, and issues a short-lived join token if it is valid. Returns
+// access.ErrWrongPasscode if it is not, or access.ErrTooManyAttempts if the client must wait before trying again.
+func (r *Room) VerifyPasscode(passcode string, client string) (string, time.Time, error) {
+	accessControl := r.gameProcessor.Access()
+	if err := accessControl.VerifyPasscode(passcode, client); err != nil {
+		return "", time.Time{}, err
+	}
+	token, expiry := accessControl.IssueJoinToken()
+	return token, expiry, nil
+}
+
+// Authorize checks whether the user may connect to the room with the join token provided in the request's "token"
+// query parameter. The passcode itself is never accepted in the URL, which may be logged.
+func (r *Room) Authorize(userID string, req *http.Request) bool {
+	return r.gameProcessor.Access().Authorize(userID, req.URL.Query().Get("token"))
+}
+
+// HandleEvent handles an event sent by a user of the room outside of their WebSocket connection, such as through the
+// REST API. Returns the *events.ActionError which rejected the event, or state.ErrRoomClosed if the room has been closed.
+func (r *Room) HandleEvent(userID string, event events.GameEvent) error {
+	return r.gameProcessor.HandleEvent(userID, event)
+}
+
+// Listing summarizes the room for the lobby browser. The second return value is false if the room has been closed.
+func (r *Room) Listing() (model.RoomListing, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return model.RoomListing{}, false
+	}
+	return r.gameProcessor.Listing(), true
+}
+
+// Summary describes the room to a client that has not joined it. The second return value is false if the room has been
+// closed.
+func (r *Room) Summary() (model.RoomSummary, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return model.RoomSummary{}, false
+	}
+	return r.gameProcessor.RoomSummary(), true
+}
+
 // addUser registers a user who has joined the room.
 func (r *Room) addUser(user *user.User) {
 	r.userMu.Lock()
@@ -130,10 +226,30 @@ func (r *Room) ConnectionHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if !r.Authorize(userKSUID.String(), req) {
+		log.Debug().Str("uid", userKSUID.String()).Msg("User is not authorized to join the room.")
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
 	// Save user ID and name to connection context
 	ctx := context.WithValue(req.Context(), ctxs.KeyUserID, userKSUID)
 	ctx = context.WithValue(ctx, ctxs.KeyUserName, userName)
 
+	// Save the protocol version and capabilities declared by the user's client
+	handshake, err := events.ParseHandshake(req.URL.Query())
+	if err != nil {
+		log.Debug().Err(err).Str("uid", userKSUID.String()).Msg("User's client sent an invalid handshake.")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx = context.WithValue(ctx, ctxs.KeyHandshake, handshake)
+
+	// Save the last event a reconnecting user has received, so that they can resume from it
+	if lastSeq, err := strconv.ParseUint(req.URL.Query().Get("lastSeq"), 10, 64); err == nil {
+		ctx = context.WithValue(ctx, ctxs.KeyLastSeq, lastSeq)
+	}
+
 	conn, err := ws.Accept(w, req)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not upgrade connection from user to a WebSocket connection.")
@@ -156,7 +272,13 @@ func (r *Room) ConnectionHandler(w http.ResponseWriter, req *http.Request) {
 // This method blocks after a user is added to the room, and waits until an error is encountered from either reading
 // from the user's WebSocket connection, or when the server fails to write to the user's connection.
 func (r *Room) newUserConnection(ctx context.Context, conn *websocket.Conn) error {
-	connErrChan := make(chan error)
+	// Both the reader and writer loops may report an error, so the channel is buffered to avoid blocking either of them
+	connErrChan := make(chan error, 2)
+
+	// The reader and writer loops outlive this handler by a little, so they are given a context that is cancelled before
+	// the handler returns, as the request's context is recycled by the router afterwards
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	userKSUID, ok := ctxs.UserID(ctx)
 	if !ok {
@@ -172,7 +294,12 @@ func (r *Room) newUserConnection(ctx context.Context, conn *websocket.Conn) erro
 		Name: userName,
 	}
 
-	u := user.NewUser(conn, userModel)
+	handshake, _ := ctxs.Handshake(ctx)
+	u := user.NewUser(conn, userModel, handshake)
+	if !handshake.LegacyEventTypes() {
+		// Greet the client before any event from the room
+		u.SendEvent(events.Welcome(handshake, conn.Subprotocol()), 0)
+	}
 
 	r.addUser(u)
 	defer r.removeUser(u)
@@ -183,13 +310,16 @@ func (r *Room) newUserConnection(ctx context.Context, conn *websocket.Conn) erro
 		Str("uname", u.Name).
 		Msg("Added new user to room")
 
-	r.gameProcessor.HandleUserConnection(ctx, u, connErrChan)
+	if err := r.gameProcessor.HandleUserConnection(ctx, u, connErrChan); err != nil {
+		_ = conn.Close(websocket.StatusPolicyViolation, err.Error())
+		return err
+	}
 
 	// blocks on waiting for an error to be sent to the connErrChan.
 	// an error is sent through the channel if a user's connection either fails to be read from or written to
 	err := <-connErrChan
 
-	r.gameProcessor.RemoveUserConnection(userModel.ID)
+	r.gameProcessor.RemoveUserConnection(u)
 	// user is removed after this function exits due to the defer statement
 
 	return err
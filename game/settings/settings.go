@@ -1,5 +1,10 @@
 package settings
 
+import (
+	"errors"
+	"fmt"
+)
+
 const (
 	MaxPlayers                   int = 8
 	MaxRounds                    int = 2
@@ -12,6 +17,46 @@ const (
 	FirstHintTimeLeftSeconds     int = 20
 	SecondHintTimeLeftSeconds    int = 15
 	ThirdHintTimeLeftSeconds     int = 10
+	RoomLeaderGracePeriodSeconds int = 30
+	DrawerGracePeriodSeconds     int = 15
+)
+
+// Scoring rules that a room awards points for correct guesses by
+const (
+	// ScoringClassic awards 3 points to the first guesser and 2 points to the drawer, then 1 point to every other
+	// guesser
+	ScoringClassic = "classic"
+	// ScoringTimeDecay awards more points the sooner the word is guessed, and the harder the word is
+	ScoringTimeDecay = "time_decay"
+	// ScoringRankLadder awards more points to earlier guessers, and the harder the word is
+	ScoringRankLadder = "rank_ladder"
+	// ScoringDrawerPerGuesser awards the drawer points for every player who guesses the word
+	ScoringDrawerPerGuesser = "drawer_per_guesser"
+)
+
+// ScoringRules lists every scoring rule
+var ScoringRules = []string{ScoringClassic, ScoringTimeDecay, ScoringRankLadder, ScoringDrawerPerGuesser}
+
+// Bounds for the settings that a room leader is allowed to configure
+const (
+	minPlayers                int = 2
+	maxPlayersLimit           int = 16
+	minRounds                 int = 1
+	maxRoundsLimit            int = 10
+	minSelectableWords        int = 1
+	maxSelectableWordsLimit   int = 5
+	minTurnNextPlayerSeconds  int = 1
+	maxTurnNextPlayerSeconds  int = 10
+	minTurnSelectionSeconds   int = 3
+	maxTurnSelectionSeconds   int = 30
+	minTurnDrawingSeconds     int = 15
+	maxTurnDrawingSeconds     int = 240
+	minTurnDrawingCutSeconds  int = 1
+	minTurnEndSeconds         int = 1
+	maxTurnEndSeconds         int = 15
+	maxHints                  int = 5
+	maxRoomLeaderGraceSeconds int = 300
+	maxDrawerGraceSeconds     int = 60
 )
 
 type GameSettings struct {
@@ -24,6 +69,24 @@ type GameSettings struct {
 	MaxTurnDrawingTimeCutSeconds int   // do not include in JSON
 	MaxTurnEndTimeSeconds        int   `json:"maxTurnEndSec"`
 	HintSettings                 []int `json:"hints"`
+	RoomLeaderGracePeriodSeconds int   `json:"leaderGraceSec"`
+	DrawerGracePeriodSeconds     int   `json:"drawerGraceSec"`
+	// Words selects the word packs, categories and difficulties that the room's words are drawn from
+	Words WordFilter `json:"words"`
+	// Scoring is the rule that points are awarded for correct guesses by, which is the classic rule if empty
+	Scoring string `json:"scoring"`
+}
+
+// WordFilter selects the words that a room draws from, out of the loaded word packs. Empty lists do not filter.
+type WordFilter struct {
+	// Packs are the names of the packs to draw words from
+	Packs []string `json:"packs"`
+	// Categories are the categories of words to draw from
+	Categories []string `json:"categories"`
+	// Difficulties are the difficulties of words to draw from
+	Difficulties []string `json:"difficulties"`
+	// Tags are the content tags that every word must have, such as "family-safe"
+	Tags []string `json:"tags"`
 }
 
 func DefaultSettings() GameSettings {
@@ -41,5 +104,95 @@ func DefaultSettings() GameSettings {
 			SecondHintTimeLeftSeconds,
 			ThirdHintTimeLeftSeconds,
 		},
+		RoomLeaderGracePeriodSeconds: RoomLeaderGracePeriodSeconds,
+		DrawerGracePeriodSeconds:     DrawerGracePeriodSeconds,
+		Scoring:                      ScoringClassic,
+	}
+}
+
+func checkBounds(name string, value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s must be between %d and %d, got %d", name, min, max, value)
+	}
+	return nil
+}
+
+// Validate checks that every setting is within the bounds allowed for a room. The hint timings must be given in
+// strictly decreasing order of time left, and each must fall within the drawing time.
+func (s GameSettings) Validate() error {
+	if err := checkBounds("max players", s.MaxPlayers, minPlayers, maxPlayersLimit); err != nil {
+		return err
+	}
+	if err := checkBounds("max rounds", s.MaxRounds, minRounds, maxRoundsLimit); err != nil {
+		return err
+	}
+	if err := checkBounds(
+		"selectable words", s.MaxSelectableWords, minSelectableWords, maxSelectableWordsLimit,
+	); err != nil {
+		return err
+	}
+	if err := checkBounds(
+		"next player time", s.MaxTurnNextPlayerTimeSeconds, minTurnNextPlayerSeconds, maxTurnNextPlayerSeconds,
+	); err != nil {
+		return err
+	}
+	if err := checkBounds(
+		"word selection time", s.MaxTurnSelectionTimeSeconds, minTurnSelectionSeconds, maxTurnSelectionSeconds,
+	); err != nil {
+		return err
+	}
+	if err := checkBounds(
+		"drawing time", s.MaxTurnDrawingTimeSeconds, minTurnDrawingSeconds, maxTurnDrawingSeconds,
+	); err != nil {
+		return err
+	}
+	if err := checkBounds(
+		"drawing time cut", s.MaxTurnDrawingTimeCutSeconds, minTurnDrawingCutSeconds, s.MaxTurnDrawingTimeSeconds,
+	); err != nil {
+		return err
+	}
+	if err := checkBounds("turn end time", s.MaxTurnEndTimeSeconds, minTurnEndSeconds, maxTurnEndSeconds); err != nil {
+		return err
+	}
+
+	if err := checkBounds(
+		"room leader grace period", s.RoomLeaderGracePeriodSeconds, 0, maxRoomLeaderGraceSeconds,
+	); err != nil {
+		return err
+	}
+
+	if err := checkBounds(
+		"drawer grace period", s.DrawerGracePeriodSeconds, 0, maxDrawerGraceSeconds,
+	); err != nil {
+		return err
+	}
+
+	if !validScoring(s.Scoring) {
+		return fmt.Errorf("unknown scoring rule %q", s.Scoring)
+	}
+
+	if len(s.HintSettings) > maxHints {
+		return fmt.Errorf("at most %d hints can be given, got %d", maxHints, len(s.HintSettings))
+	}
+	for i, timeLeft := range s.HintSettings {
+		if err := checkBounds("hint time", timeLeft, 1, s.MaxTurnDrawingTimeSeconds-1); err != nil {
+			return err
+		}
+		if i > 0 && timeLeft >= s.HintSettings[i-1] {
+			return errors.New("hint times must be in strictly decreasing order")
+		}
+	}
+	return nil
+}
+
+func validScoring(scoring string) bool {
+	if scoring == "" {
+		return true
+	}
+	for _, rule := range ScoringRules {
+		if rule == scoring {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,224 @@
+package access
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/kvnxiao/pictorio/clock"
+)
+
+const (
+	// JoinTokenTTL is how long a join token issued after verifying the passcode remains valid, unless it is used
+	JoinTokenTTL    = 60 * time.Second
+	joinTokenLength = 32
+	saltLength      = 16
+
+	// MaxPasscodeLength is the maximum length of a room passcode
+	MaxPasscodeLength = 64
+
+	// MaxFailedAttempts is the number of wrong passcodes that a client may try within FailedAttemptsWindow
+	MaxFailedAttempts = 5
+	// MaxRoomFailedAttempts is the number of wrong passcodes that all clients together may try within
+	// FailedAttemptsWindow, which bounds guessing from many addresses
+	MaxRoomFailedAttempts = 50
+	// FailedAttemptsWindow is how long a failed passcode attempt is counted for
+	FailedAttemptsWindow = time.Minute
+)
+
+var (
+	ErrWrongPasscode   = errors.New("wrong passcode")
+	ErrTooManyAttempts = errors.New("too many failed passcode attempts")
+)
+
+// Control guards who may connect to a room
+type Control interface {
+	RequiresPasscode() bool
+	SetPasscode(passcode string)
+	VerifyPasscode(passcode string, client string) error
+
+	IssueJoinToken() (string, time.Time)
+	Authorize(userID string, token string) bool
+	Revoke(userID string)
+}
+
+// Gate is a Control which requires a short-lived, single-use join token obtained by verifying the room's optional
+// passcode beforehand. Users who have been admitted once are remembered, so that they can reconnect without a token.
+type Gate struct {
+	mu           sync.Mutex
+	salt         []byte
+	passcodeHash []byte
+	joinTokens   map[string]time.Time
+	admitted     map[string]struct{}
+
+	clock clock.Clock
+	// failures counts the failed passcode attempts of each client, and roomFailures those of every client together
+	failures     map[string]*failedAttempts
+	roomFailures failedAttempts
+}
+
+// failedAttempts counts the failed passcode attempts since the first attempt in the current window
+type failedAttempts struct {
+	count int
+	since time.Time
+}
+
+// expired returns true if the window of the failed attempts has passed
+func (f *failedAttempts) expired(now time.Time) bool {
+	return !now.Before(f.since.Add(FailedAttemptsWindow))
+}
+
+// ValidPasscode checks that a passcode is within the allowed length. An empty passcode is valid and means the room does
+// not require a passcode.
+func ValidPasscode(passcode string) bool {
+	return len(passcode) <= MaxPasscodeLength
+}
+
+func NewGate(passcode string) Control {
+	return newGate(passcode, clock.Real())
+}
+
+func newGate(passcode string, clk clock.Clock) *Gate {
+	g := &Gate{
+		joinTokens: make(map[string]time.Time),
+		admitted:   make(map[string]struct{}),
+		clock:      clk,
+		failures:   make(map[string]*failedAttempts),
+	}
+	g.SetPasscode(passcode)
+	return g
+}
+
+func (g *Gate) hash(passcode string) []byte {
+	h := sha256.New()
+	h.Write(g.salt)
+	h.Write([]byte(passcode))
+	return h.Sum(nil)
+}
+
+func (g *Gate) RequiresPasscode() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.passcodeHash != nil
+}
+
+// SetPasscode changes the room's passcode, or removes it if empty. Previously issued join tokens are revoked.
+func (g *Gate) SetPasscode(passcode string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.joinTokens = make(map[string]time.Time)
+	if passcode == "" {
+		g.salt = nil
+		g.passcodeHash = nil
+		return
+	}
+
+	salt := make([]byte, saltLength)
+	_, _ = rand.Read(salt)
+	g.salt = salt
+	g.passcodeHash = g.hash(passcode)
+}
+
+// VerifyPasscode checks a passcode tried by a client, such as the address of the request. Returns ErrTooManyAttempts
+// without checking the passcode if the client, or every client together, has tried too many wrong passcodes recently.
+func (g *Gate) VerifyPasscode(passcode string, client string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	for c, failures := range g.failures {
+		if failures.expired(now) {
+			delete(g.failures, c)
+		}
+	}
+	if g.roomFailures.expired(now) {
+		g.roomFailures = failedAttempts{}
+	}
+
+	failures, ok := g.failures[client]
+	if ok && failures.count >= MaxFailedAttempts || g.roomFailures.count >= MaxRoomFailedAttempts {
+		return ErrTooManyAttempts
+	}
+	if g.verifyPasscode(passcode) {
+		return nil
+	}
+
+	if !ok {
+		failures = &failedAttempts{since: now}
+		g.failures[client] = failures
+	}
+	failures.count++
+	if g.roomFailures.count == 0 {
+		g.roomFailures.since = now
+	}
+	g.roomFailures.count++
+	return ErrWrongPasscode
+}
+
+// verifyPasscode must be called while holding the lock
+func (g *Gate) verifyPasscode(passcode string) bool {
+	if g.passcodeHash == nil {
+		return true
+	}
+	return subtle.ConstantTimeCompare(g.hash(passcode), g.passcodeHash) == 1
+}
+
+// IssueJoinToken creates a new join token, returning the token and its expiry time
+func (g *Gate) IssueJoinToken() (string, time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	for token, expiry := range g.joinTokens {
+		if now.After(expiry) {
+			delete(g.joinTokens, token)
+		}
+	}
+
+	token := uniuri.NewLen(joinTokenLength)
+	expiry := now.Add(JoinTokenTTL)
+	g.joinTokens[token] = expiry
+	return token, expiry
+}
+
+// Authorize checks whether a user may connect to the room with a valid join token, which is used up. Users who are
+// authorized with a non-empty user ID are admitted, and do not need to present a join token when reconnecting.
+func (g *Gate) Authorize(userID string, token string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.passcodeHash == nil {
+		return true
+	}
+	if _, ok := g.admitted[userID]; ok && userID != "" {
+		return true
+	}
+
+	expiry, ok := g.joinTokens[token]
+	if !ok || token == "" {
+		return false
+	}
+	delete(g.joinTokens, token)
+	if !g.clock.Now().Before(expiry) {
+		return false
+	}
+
+	if userID != "" {
+		g.admitted[userID] = struct{}{}
+	}
+	return true
+}
+
+// Revoke forgets that a user was admitted, so that they must present the passcode again to reconnect.
+func (g *Gate) Revoke(userID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.admitted, userID)
+}
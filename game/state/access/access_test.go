@@ -0,0 +1,148 @@
+package access
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+)
+
+const testUserID = "1mZlaYVCTdKa1mxDE5JDGWQhyUr"
+
+func newTestGate(passcode string) (*Gate, *clock.Manual) {
+	clk := clock.NewManual(time.Unix(1600000000, 0))
+	return newGate(passcode, clk), clk
+}
+
+func TestVerifyPasscode(t *testing.T) {
+	tests := map[string]struct {
+		passcode string
+		tried    string
+		err      error
+	}{
+		"correct passcode":       {"s3cret", "s3cret", nil},
+		"wrong passcode":         {"s3cret", "secret", ErrWrongPasscode},
+		"empty passcode":         {"s3cret", "", ErrWrongPasscode},
+		"prefix of the passcode": {"s3cret", "s3cre", ErrWrongPasscode},
+		"no passcode required":   {"", "anything", nil},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			g, _ := newTestGate(test.passcode)
+			if g.RequiresPasscode() != (test.passcode != "") {
+				t.Errorf("requires a passcode: %v", g.RequiresPasscode())
+			}
+			if err := g.VerifyPasscode(test.tried, "client"); err != test.err {
+				t.Errorf("verified %q with error %v, expected %v", test.tried, err, test.err)
+			}
+		})
+	}
+}
+
+func TestJoinTokenIsSingleUse(t *testing.T) {
+	g, _ := newTestGate("s3cret")
+
+	token, _ := g.IssueJoinToken()
+	if !g.Authorize("", token) {
+		t.Fatalf("join token was not accepted")
+	}
+	if g.Authorize("", token) {
+		t.Errorf("join token was accepted twice")
+	}
+	if g.Authorize("", "") || g.Authorize("", "not a token") {
+		t.Errorf("accepted a missing or unknown join token")
+	}
+}
+
+func TestJoinTokenExpires(t *testing.T) {
+	g, clk := newTestGate("s3cret")
+
+	token, expiry := g.IssueJoinToken()
+	if !expiry.Equal(clk.Now().Add(JoinTokenTTL)) {
+		t.Errorf("join token expires at %v, expected %v", expiry, clk.Now().Add(JoinTokenTTL))
+	}
+	valid, _ := g.IssueJoinToken()
+
+	clk.Advance(JoinTokenTTL - time.Second)
+	if !g.Authorize("", valid) {
+		t.Errorf("join token expired early")
+	}
+	clk.Advance(time.Second)
+	if g.Authorize("", token) {
+		t.Errorf("expired join token was accepted")
+	}
+}
+
+func TestAdmittedUsersReconnect(t *testing.T) {
+	g, _ := newTestGate("s3cret")
+
+	if g.Authorize(testUserID, "") {
+		t.Fatalf("user was authorized without a join token")
+	}
+	token, _ := g.IssueJoinToken()
+	if !g.Authorize(testUserID, token) {
+		t.Fatalf("join token was not accepted")
+	}
+	if !g.Authorize(testUserID, "") {
+		t.Errorf("admitted user could not reconnect without a join token")
+	}
+
+	g.Revoke(testUserID)
+	if g.Authorize(testUserID, "") {
+		t.Errorf("revoked user reconnected without a join token")
+	}
+
+	// Changing the passcode revokes the join tokens which have not been used
+	token, _ = g.IssueJoinToken()
+	g.SetPasscode("changed")
+	if g.Authorize(testUserID, token) {
+		t.Errorf("join token was accepted after the passcode changed")
+	}
+}
+
+func TestFailedAttemptsAreLimited(t *testing.T) {
+	g, clk := newTestGate("s3cret")
+
+	for i := 0; i < MaxFailedAttempts; i++ {
+		if err := g.VerifyPasscode("wrong", "client"); err != ErrWrongPasscode {
+			t.Fatalf("attempt %d failed with %v", i, err)
+		}
+	}
+	// The correct passcode is not even checked once the client has tried too many wrong passcodes
+	if err := g.VerifyPasscode("s3cret", "client"); err != ErrTooManyAttempts {
+		t.Errorf("verified the passcode with error %v, expected too many attempts", err)
+	}
+	if err := g.VerifyPasscode("s3cret", "other client"); err != nil {
+		t.Errorf("another client could not verify the passcode: %v", err)
+	}
+
+	clk.Advance(FailedAttemptsWindow)
+	if err := g.VerifyPasscode("s3cret", "client"); err != nil {
+		t.Errorf("client could not verify the passcode after waiting: %v", err)
+	}
+}
+
+func TestFailedAttemptsAreLimitedAcrossClients(t *testing.T) {
+	g, clk := newTestGate("s3cret")
+
+	for i := 0; i < MaxRoomFailedAttempts; i++ {
+		if err := g.VerifyPasscode("wrong", "client "+strconv.Itoa(i)); err != ErrWrongPasscode {
+			t.Fatalf("attempt %d failed with %v", i, err)
+		}
+	}
+	if err := g.VerifyPasscode("s3cret", "new client"); err != ErrTooManyAttempts {
+		t.Errorf("verified the passcode with error %v, expected too many attempts", err)
+	}
+	if len(g.failures) > MaxRoomFailedAttempts {
+		t.Errorf("counted the failures of %d clients", len(g.failures))
+	}
+
+	clk.Advance(FailedAttemptsWindow)
+	if err := g.VerifyPasscode("s3cret", "new client"); err != nil {
+		t.Errorf("could not verify the passcode after waiting: %v", err)
+	}
+	if len(g.failures) != 0 {
+		t.Errorf("kept the failures of %d clients after they expired", len(g.failures))
+	}
+}
@@ -1,22 +1,79 @@
 package drawing
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/kvnxiao/pictorio/model"
 )
 
+const (
+	defaultMaxStrokePoints = 1024
+	defaultMaxStrokes      = 256
+)
+
+var (
+	ErrTooManyPoints  = errors.New("the line has too many points")
+	ErrTooManyStrokes = errors.New("the drawing has too many lines")
+)
+
+// Limits bounds the size of a drawing, which is sent in full to every user who joins the room during a turn
+type Limits struct {
+	// MaxStrokePoints is the maximum number of points in a line
+	MaxStrokePoints int
+	// MaxStrokes is the maximum number of lines in the drawing of a turn
+	MaxStrokes int
+}
+
+var (
+	limitsMu sync.RWMutex
+	limits   = Limits{
+		MaxStrokePoints: defaultMaxStrokePoints,
+		MaxStrokes:      defaultMaxStrokes,
+	}
+)
+
+// Configure sets the limits of new drawing histories. Zero values fall back to the defaults.
+func Configure(l Limits) {
+	if l.MaxStrokePoints <= 0 {
+		l.MaxStrokePoints = defaultMaxStrokePoints
+	}
+	if l.MaxStrokes <= 0 {
+		l.MaxStrokes = defaultMaxStrokes
+	}
+
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+
+	limits = l
+}
+
+func getLimits() Limits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+
+	return limits
+}
+
 type History interface {
 	Append(line model.Line) bool
-	AppendFromTempLine(tempLine model.Line)
+	AppendFromTempLine(tempLine model.Line) error
+	Full() bool
 	PromoteLine() bool
 	SetTempColour(colourIdx int)
 	SetTempThickness(thicknessIdx int)
 	GetAll() []model.Line
+	TempLine() (model.Line, bool)
 	Redo() bool
 	Undo() bool
 	Clear() bool
+	Reset()
 }
 
 type Drawing struct {
+	limits Limits
+	// strokes counts the lines drawn during the turn, including lines which have since been undone or cleared
+	strokes       int
 	tempColour    int
 	tempThickness int
 	tempPoints    []model.Point
@@ -26,6 +83,7 @@ type Drawing struct {
 
 func NewDrawingHistory() History {
 	return &Drawing{
+		limits:     getLimits(),
 		tempPoints: nil,
 		lines:      nil,
 		redoStack:  nil,
@@ -33,21 +91,53 @@ func NewDrawingHistory() History {
 }
 
 func (d *Drawing) Append(line model.Line) bool {
-	d.lines = append(d.lines, line)
+	if d.Full() || len(line.Points) > d.limits.MaxStrokePoints {
+		return false
+	}
+	d.addLine(line)
 	return true
 }
 
-func (d *Drawing) AppendFromTempLine(tempLine model.Line) {
+// addLine adds a newly drawn line to the drawing, which discards the lines that could be redone
+func (d *Drawing) addLine(line model.Line) {
+	d.lines = append(d.lines, line)
+	d.redoStack = nil
+	d.strokes++
+}
+
+// AppendFromTempLine appends points to the line being drawn, returning ErrTooManyStrokes if the drawing cannot have
+// another line, or ErrTooManyPoints if the line would have too many points. Nothing is appended if an error is
+// returned.
+func (d *Drawing) AppendFromTempLine(tempLine model.Line) error {
+	if d.Full() {
+		return ErrTooManyStrokes
+	}
+	if len(d.tempPoints)+len(tempLine.Points) > d.limits.MaxStrokePoints {
+		return ErrTooManyPoints
+	}
+
 	d.tempColour = tempLine.ColourIdx
 	d.tempThickness = tempLine.ThicknessIdx
 	d.tempPoints = append(d.tempPoints, tempLine.Points...)
+	return nil
+}
+
+// Full returns true if as many lines have been drawn during the turn as are allowed. Undoing or clearing lines does not
+// allow more lines to be drawn.
+func (d *Drawing) Full() bool {
+	return d.strokes >= d.limits.MaxStrokes
 }
 
 func (d *Drawing) PromoteLine() bool {
+	if d.Full() {
+		d.tempPoints = nil
+		return false
+	}
+
 	finalPoints := make([]model.Point, len(d.tempPoints))
 	copy(finalPoints, d.tempPoints)
 
-	d.lines = append(d.lines, model.Line{
+	d.addLine(model.Line{
 		Points:       finalPoints,
 		ColourIdx:    d.tempColour,
 		ThicknessIdx: d.tempThickness,
@@ -70,8 +160,23 @@ func (d *Drawing) GetAll() []model.Line {
 	return allLines
 }
 
+// TempLine returns the line being drawn, or false if no line is being drawn
+func (d *Drawing) TempLine() (model.Line, bool) {
+	if len(d.tempPoints) == 0 {
+		return model.Line{}, false
+	}
+
+	points := make([]model.Point, len(d.tempPoints))
+	copy(points, d.tempPoints)
+	return model.Line{
+		Points:       points,
+		ColourIdx:    d.tempColour,
+		ThicknessIdx: d.tempThickness,
+	}, true
+}
+
 func (d *Drawing) Redo() bool {
-	// No-op if no lines to redo
+	// No-op if no lines to redo. A redone line was already counted when it was drawn.
 	if len(d.redoStack) <= 0 {
 		return false
 	}
@@ -109,3 +214,9 @@ func (d *Drawing) Clear() bool {
 
 	return true
 }
+
+// Reset clears the drawing at the end of a turn, which allows as many lines to be drawn again
+func (d *Drawing) Reset() {
+	d.Clear()
+	d.strokes = 0
+}
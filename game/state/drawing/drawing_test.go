@@ -0,0 +1,110 @@
+package drawing
+
+import (
+	"testing"
+
+	"github.com/kvnxiao/pictorio/model"
+)
+
+var testLimits = Limits{MaxStrokePoints: 4, MaxStrokes: 3}
+
+func line(points int) model.Line {
+	return model.Line{Points: make([]model.Point, points)}
+}
+
+// draw draws a line of a single point, returning whether the line was added to the drawing
+func draw(d *Drawing) bool {
+	if err := d.AppendFromTempLine(line(1)); err != nil {
+		return false
+	}
+	return d.PromoteLine()
+}
+
+func TestStrokePointsLimit(t *testing.T) {
+	d := &Drawing{limits: testLimits}
+
+	if err := d.AppendFromTempLine(line(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AppendFromTempLine(line(2)); err != ErrTooManyPoints {
+		t.Fatalf("appended a fifth point to a line, with error %v", err)
+	}
+	if err := d.AppendFromTempLine(line(1)); err != nil {
+		t.Fatalf("could not append the fourth point: %v", err)
+	}
+	if !d.PromoteLine() || len(d.GetAll()[0].Points) != 4 {
+		t.Errorf("drew %v, expected a line of 4 points", d.GetAll())
+	}
+
+	if d.Append(line(5)) {
+		t.Errorf("appended a line of 5 points")
+	}
+}
+
+func TestStrokesLimit(t *testing.T) {
+	tests := map[string]struct {
+		// between is done after drawing the first two lines
+		between func(d *Drawing)
+		// drawn is the number of further lines that may be drawn
+		drawn int
+	}{
+		"no changes": {func(d *Drawing) {}, 1},
+		"undo": {func(d *Drawing) {
+			d.Undo()
+			d.Undo()
+		}, 1},
+		"clear": {func(d *Drawing) {
+			d.Clear()
+		}, 1},
+		"undo and redo": {func(d *Drawing) {
+			d.Undo()
+			d.Redo()
+		}, 1},
+		"reset at the end of the turn": {func(d *Drawing) {
+			d.Reset()
+		}, 3},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := &Drawing{limits: testLimits}
+			draw(d)
+			draw(d)
+			test.between(d)
+
+			drawn := 0
+			for i := 0; i < testLimits.MaxStrokes+1 && draw(d); i++ {
+				drawn++
+			}
+			if drawn != test.drawn {
+				t.Errorf("drew %d more lines, expected %d", drawn, test.drawn)
+			}
+			if err := d.AppendFromTempLine(line(1)); err != ErrTooManyStrokes {
+				t.Errorf("started another line, with error %v", err)
+			}
+			if d.Append(line(1)) {
+				t.Errorf("appended another line")
+			}
+		})
+	}
+}
+
+func TestNewLineDiscardsRedo(t *testing.T) {
+	d := &Drawing{limits: Limits{MaxStrokePoints: 4, MaxStrokes: 100}}
+
+	// Drawing and undoing lines never keeps more than one line to redo
+	for i := 0; i < 10; i++ {
+		draw(d)
+		d.Undo()
+	}
+	if len(d.redoStack) != 1 {
+		t.Errorf("kept %d lines to redo, expected 1", len(d.redoStack))
+	}
+
+	draw(d)
+	if len(d.redoStack) != 0 || d.Redo() {
+		t.Errorf("redid a line undone before a new line was drawn")
+	}
+	if len(d.GetAll()) != 1 {
+		t.Errorf("drawing has %d lines, expected 1", len(d.GetAll()))
+	}
+}
@@ -2,21 +2,45 @@ package state
 
 import (
 	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/game/state/replay"
 )
 
 func (g *GameStateProcessor) broadcast(event events.SerializableEvent) {
-	g.players.SendEventToAll(event)
+	seq := g.replay.Append(replay.Entry{Event: event})
+	g.players.SendEventToAll(event, seq)
 }
 
 func (g *GameStateProcessor) broadcastExcluding(event events.SerializableEvent, userID string) {
-	g.players.SendEventToAllExcept(event, userID)
+	seq := g.replay.Append(replay.Entry{Event: event, Except: userID})
+	g.players.SendEventToAllExcept(event, seq, userID)
 }
 
 func (g *GameStateProcessor) emit(event events.SerializableEvent, userID string) {
-	g.players.SendEventToUser(event, userID)
+	seq := g.replay.Append(replay.Entry{Event: event, To: userID})
+	g.players.SendEventToUser(event, seq, userID)
+}
+
+// broadcastUnsequenced sends an event to every user without numbering it or recording it for replay, for events which
+// supersede each other and are sent again as part of the room's state when a user resumes their connection
+func (g *GameStateProcessor) broadcastUnsequenced(event events.SerializableEvent) {
+	g.players.SendEventToAll(event, 0)
+}
+
+// broadcastTempLine sends a drawing's temporary line segment to every user except the drawer, without recording it for
+// replay. Since the segments are far too many to replay, a user who resumes their connection after they were sent is
+// sent a snapshot of the drawing instead.
+func (g *GameStateProcessor) broadcastTempLine(event events.DrawTempEvent, userID string) {
+	g.tempLineSeq = g.replay.LastSeq()
+	g.players.SendEventToAllExcept(event, 0, userID)
+}
+
+// emitUnsequenced sends an event to a user without numbering it or recording it for replay, for events which are only
+// meaningful at the time they are sent
+func (g *GameStateProcessor) emitUnsequenced(event events.SerializableEvent, userID string) {
+	g.players.SendEventToUser(event, 0, userID)
 }
 
 func (g *GameStateProcessor) broadcastChat(chatEvent events.ChatEvent) {
 	g.chatHistory.Append(chatEvent)
-	g.players.SendEventToAll(chatEvent)
+	g.broadcast(chatEvent)
 }
@@ -1,46 +1,74 @@
 package state
 
 import (
-	"time"
-
 	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/game/state/access"
+	"github.com/kvnxiao/pictorio/game/state/drawing"
 	"github.com/kvnxiao/pictorio/model"
+	"github.com/kvnxiao/pictorio/words"
 	"github.com/rs/zerolog/log"
+	"nhooyr.io/websocket"
 )
 
-func (g *GameStateProcessor) warnServerSourcedEvent(eventType events.GameEventType) {
+// Every listener below receives the sender as the user bound to the WebSocket connection that the event was read
+// from. Any user ID / name contained in the client's payload is ignored, and replaced with the sender when the event
+// is re-broadcasted to other players.
+//
+// Listeners are registered in registry.go, and only receive events which have been decoded and validated. A listener
+// returns an *events.ActionError when it rejects the event, which is sent back to the sender's connection.
+
+func (g *GameStateProcessor) rejectServerSourcedEvent(eventType events.GameEventType) error {
 	log.Warn().
 		Str("event", eventType.String()).
 		Msg("Received a server-sourced event from a client!")
+	return events.Reject(events.ErrorServerSourcedEvent, eventType.String()+" can only be sent by the server")
+}
+
+// checkRoomLeader rejects the event if the sender is not the room leader
+func (g *GameStateProcessor) checkRoomLeader(sender model.User, eventType events.GameEventType) error {
+	if sender.ID != g.players.RoomLeaderID() {
+		return events.Reject(events.ErrorNotRoomLeader, eventType.String()+" can only be sent by the room leader")
+	}
+	return nil
 }
 
-func (g *GameStateProcessor) onChatEvent(event events.ChatEvent) {
-	// Do not process chat events from client trying to impersonate the server
-	if event.User.ID == model.SystemUserID || event.Type != events.ChatEventUser {
-		log.Error().
-			Msg("Received a " + event.GameEventType().String() + " from client with system user ID / name!")
-		return
+// checkCurrentDrawer rejects the event if the sender is not the current turn's drawer
+func (g *GameStateProcessor) checkCurrentDrawer(sender model.User, eventType events.GameEventType) error {
+	if g.status.CurrentTurnID() != sender.ID {
+		return events.Reject(events.ErrorNotDrawer, eventType.String()+" can only be sent by the current turn's drawer")
 	}
+	return nil
+}
 
+// drawingLimitError rejects a drawing event which would exceed the limits of the drawing history
+func drawingLimitError(err error) error {
+	switch err {
+	case drawing.ErrTooManyPoints:
+		return events.Reject(events.ErrorTooManyPoints, "The line has too many points")
+	case drawing.ErrTooManyStrokes:
+		return events.Reject(events.ErrorTooManyStrokes, "The drawing has too many lines")
+	default:
+		return err
+	}
+}
+
+func (g *GameStateProcessor) onChatEvent(sender model.User, event events.ChatEvent) error {
 	// Check if game is in progress and send to guess if so
-	if g.status.Status() == model.GameStarted && g.status.TurnStatus() == model.TurnDrawing {
-		g.wordGuess <- Guess{
-			User:      event.User,
-			Timestamp: time.Now().UnixNano(),
-			Value:     event.Message,
-		}
+	if g.status.Status() == model.GameStarted && g.turn.phase == phaseDrawing {
+		g.onGuess(sender, event.Message)
 	} else {
 		// Save event to chat history and broadcast
-		g.broadcastChat(events.ChatUserMessage(event.User, event.Message))
+		g.broadcastChat(events.ChatUserMessage(sender, event.Message))
 	}
+	return nil
 }
 
-func (g *GameStateProcessor) onDrawEvent(event events.DrawEvent) {
+func (g *GameStateProcessor) onDrawEvent(sender model.User, event events.DrawEvent) error {
 	// Validate drawing is from current turn's user
-	if g.status.CurrentTurnID() != event.User.ID {
-		log.Error().Msg("Received a " + event.GameEventType().String() +
-			" from a client whose user ID does not match the current turn's ID")
+	if err := g.checkCurrentDrawer(sender, event.GameEventType()); err != nil {
+		return err
 	}
+	event.User = sender
 
 	// Save event to drawing history
 	handled := false
@@ -51,117 +79,300 @@ func (g *GameStateProcessor) onDrawEvent(event events.DrawEvent) {
 		handled = g.drawingHistory.Undo()
 	case events.Redo:
 		handled = g.drawingHistory.Redo()
-	default:
-		log.Error().Msg("Unknown " + event.GameEventType().String() + " event type")
+	}
+	if !handled {
+		return events.Reject(events.ErrorNothingToUndoOrRedo, "The drawing history has nothing to undo or redo")
 	}
 
 	// Broadcast event to users
-	if handled {
-		g.broadcastExcluding(event, event.User.ID)
-	}
+	g.broadcastExcluding(event, sender.ID)
+	return nil
 }
 
-func (g *GameStateProcessor) onDrawTempEvent(event events.DrawTempEvent) {
+func (g *GameStateProcessor) onDrawTempEvent(sender model.User, event events.DrawTempEvent) error {
 	// Validate drawing is from current turn's user
-	if g.status.CurrentTurnID() != event.User.ID {
-		log.Error().Msg("Received a " + event.GameEventType().String() +
-			" from a client whose user ID does not match the current turn's ID")
+	if err := g.checkCurrentDrawer(sender, event.GameEventType()); err != nil {
+		return err
 	}
+	event.User = sender
 
-	g.drawingHistory.AppendFromTempLine(event.Line)
-	g.broadcastExcluding(event, event.User.ID)
+	if err := g.drawingHistory.AppendFromTempLine(event.Line); err != nil {
+		return drawingLimitError(err)
+	}
+	g.broadcastTempLine(event, sender.ID)
+	return nil
 }
 
-func (g *GameStateProcessor) onDrawTempStopEvent(event events.DrawTempStopEvent) {
+func (g *GameStateProcessor) onDrawTempStopEvent(sender model.User, event events.DrawTempStopEvent) error {
 	// Validate drawing is from current turn's user
-	if g.status.CurrentTurnID() != event.User.ID {
-		log.Error().Msg("Received a " + event.GameEventType().String() +
-			" from a client whose user ID does not match the current turn's ID")
+	if err := g.checkCurrentDrawer(sender, event.GameEventType()); err != nil {
+		return err
 	}
+	event.User = sender
 
-	g.drawingHistory.AppendFromTempLine(event.Line)
+	err := g.drawingHistory.AppendFromTempLine(event.Line)
+	if err == drawing.ErrTooManyPoints {
+		// End the line with the points which were accepted, so that other users stop drawing it too
+		event.Line.Points = nil
+	} else if err != nil {
+		return drawingLimitError(err)
+	}
 	g.drawingHistory.PromoteLine()
 	g.broadcast(event)
+	if err != nil {
+		return drawingLimitError(err)
+	}
+	return nil
 }
 
-func (g *GameStateProcessor) onDrawSelectColour(event events.DrawSelectColourEvent) {
+func (g *GameStateProcessor) onDrawSelectColour(sender model.User, event events.DrawSelectColourEvent) error {
 	// Validate drawing is from current turn's user
-	if g.status.CurrentTurnID() != event.User.ID {
-		log.Error().Msg("Received a " + event.GameEventType().String() +
-			" from a client whose user ID does not match the current turn's ID")
+	if err := g.checkCurrentDrawer(sender, event.GameEventType()); err != nil {
+		return err
 	}
+	event.User = sender
 
 	g.drawingHistory.SetTempColour(event.ColourIndex)
-	g.broadcastExcluding(event, event.User.ID)
+	g.broadcastExcluding(event, sender.ID)
+	return nil
 }
 
-func (g *GameStateProcessor) onDrawSelectThickness(event events.DrawSelectThicknessEvent) {
+func (g *GameStateProcessor) onDrawSelectThickness(sender model.User, event events.DrawSelectThicknessEvent) error {
 	// Validate drawing is from current turn's user
-	if g.status.CurrentTurnID() != event.User.ID {
-		log.Error().Msg("Received a " + event.GameEventType().String() +
-			" from a client whose user ID does not match the current turn's ID")
+	if err := g.checkCurrentDrawer(sender, event.GameEventType()); err != nil {
+		return err
 	}
+	event.User = sender
 
 	g.drawingHistory.SetTempThickness(event.ThicknessIndex)
-	g.broadcastExcluding(event, event.User.ID)
+	g.broadcastExcluding(event, sender.ID)
+	return nil
 }
 
-func (g *GameStateProcessor) onReadyEvent(event events.ReadyEvent) {
-	ready := g.players.ReadyPlayer(event.User.ID, event.Ready)
-	g.broadcast(events.ReadyEvent{User: event.User, Ready: ready})
+func (g *GameStateProcessor) onReadyEvent(sender model.User, event events.ReadyEvent) error {
+	ready, ok := g.players.ReadyPlayer(sender.ID, event.Ready)
+	if !ok {
+		return events.Reject(events.ErrorNotInRoom, "Not in the room")
+	}
+	g.broadcast(events.ReadyEvent{User: sender, Ready: ready})
+	return nil
 }
 
-func (g *GameStateProcessor) onStartGameIssuedEvent(event events.StartGameIssuedEvent) {
+func (g *GameStateProcessor) onStartGameIssuedEvent(sender model.User, _ events.StartGameIssuedEvent) error {
 	// Validate the issuer is the room leader
-	if event.Issuer.ID != g.players.RoomLeaderID() {
-		log.Error().
-			Msg("Received a " + events.EventTypeStartGameIssued.String() +
-				" from client who was not the room leader!")
-		return
+	if err := g.checkRoomLeader(sender, events.EventTypeStartGameIssued); err != nil {
+		return err
 	}
 
 	// Validate that the game has not already started
 	if g.status.Status() == model.GameStarted {
-		log.Error().Msg("Received a " + events.EventTypeStartGameIssued.String() +
-			" event from the room leader, but the game has already started!")
-		return
+		return events.Reject(events.ErrorInvalidGameStatus, "The game has already started")
 	}
 
-	started := g.StartGame()
-	if !started {
-		log.Warn().Msg("Failed to start game!")
+	if err := g.startGame(); err != nil {
+		return err
 	}
 	log.Debug().Msg("Game started!")
+	return nil
 }
 
-func (g *GameStateProcessor) onTurnWordSelectedEvent(event events.TurnWordSelectedEvent) {
+func (g *GameStateProcessor) onTurnWordSelectedEvent(sender model.User, event events.TurnWordSelectedEvent) error {
 	// Validate the user who sent this event is the current turn's user
-	if event.User.ID != g.status.CurrentTurnID() {
-		log.Error().Msg("Received a " + events.EventTypeTurnWordSelected.String() +
-			" event from a player who's turn is not the current turn.")
-		return
+	if err := g.checkCurrentDrawer(sender, events.EventTypeTurnWordSelected); err != nil {
+		return err
 	}
 
-	g.wordSelectionIndex <- SelectionIndex{
-		User:      event.User,
-		Timestamp: time.Now().UnixNano(),
-		Value:     event.Index,
+	// Validate the drawer is still selecting a word
+	if g.turn.phase != phaseWordSelection {
+		return events.Reject(events.ErrorInvalidTurnPhase, "A word can only be selected during word selection")
 	}
+
+	if event.Index < 0 || event.Index >= len(g.turn.wordSelections) {
+		return events.Reject(events.ErrorInvalidWordIndex, "Word selection index is out of bounds")
+	}
+
+	selectedWord := g.turn.wordSelections[event.Index]
+	log.Debug().
+		Str("selectedWord", selectedWord).
+		Msg("A word has been selected by the drawer")
+	g.selectWord(selectedWord)
+	return nil
 }
 
-func (g *GameStateProcessor) onNewGameIssued(event events.NewGameIssuedEvent) {
+func (g *GameStateProcessor) onNewGameIssued(sender model.User, _ events.NewGameIssuedEvent) error {
 	// Validate the issuer is the room leader
-	if event.Issuer.ID != g.players.RoomLeaderID() {
-		log.Error().
-			Msg("Received a " + events.EventTypeNewGameIssued.String() +
-				" from client who was not the room leader!")
-		return
+	if err := g.checkRoomLeader(sender, events.EventTypeNewGameIssued); err != nil {
+		return err
 	}
 
+	g.stopGame()
+	g.drawingHistory.Reset()
 	g.status.Reset()
 	g.players.Reset()
 	g.status.SetStatus(model.GameWaitingReadyUp)
 	g.broadcast(events.NewGameResetEvent{
 		PlayerStates: g.players.Summary().PlayerStates,
 	})
+	return nil
+}
+
+func (g *GameStateProcessor) onUpdateSettingsEvent(sender model.User, event events.UpdateSettingsEvent) error {
+	// Validate the issuer is the room leader
+	if err := g.checkRoomLeader(sender, events.EventTypeUpdateSettings); err != nil {
+		return err
+	}
+
+	// Settings can only be changed while players are readying up
+	if g.status.Status() != model.GameWaitingReadyUp {
+		return events.Reject(events.ErrorInvalidGameStatus,
+			"Settings can only be changed while waiting for players to ready up")
+	}
+
+	newSettings := event.Settings
+
+	// Do not shrink the room below the number of players already in it
+	if newSettings.MaxPlayers < g.players.PlayerCount() {
+		return events.Reject(events.ErrorInvalidSettings,
+			"Max players cannot be less than the number of players in the room")
+	}
+	// Words can only be drawn from the word packs which are loaded
+	err := words.Default().Validate(newSettings.Words, g.status.CustomWords(), newSettings.MaxSelectableWords)
+	if err != nil {
+		return events.Reject(events.ErrorInvalidSettings, err.Error())
+	}
+
+	g.status.SetSettings(newSettings)
+	g.players.SetMaxPlayers(newSettings.MaxPlayers)
+	g.broadcast(events.UpdateSettingsEvent{User: sender, Settings: newSettings})
+	return nil
+}
+
+func (g *GameStateProcessor) onCustomWordsEvent(sender model.User, event events.CustomWordsEvent) error {
+	// Validate the issuer is the room leader
+	if err := g.checkRoomLeader(sender, events.EventTypeCustomWords); err != nil {
+		return err
+	}
+
+	// Custom words can only be changed while players are readying up, like the settings
+	if g.status.Status() != model.GameWaitingReadyUp {
+		return events.Reject(events.ErrorInvalidGameStatus,
+			"Custom words can only be changed while waiting for players to ready up")
+	}
+
+	list, err := event.List()
+	if err != nil {
+		return events.Reject(events.ErrorInvalidWords, err.Error())
+	}
+	maxSelectableWords := g.status.Settings().MaxSelectableWords
+	if err := words.Default().Validate(g.status.Settings().Words, list, maxSelectableWords); err != nil {
+		return events.Reject(events.ErrorInvalidWords, err.Error())
+	}
+
+	g.status.SetCustomWords(list)
+	g.emit(events.CustomWordsUploaded(sender, list, true), sender.ID)
+	g.broadcastExcluding(events.CustomWordsUploaded(sender, list, false), sender.ID)
+	return nil
+}
+
+func (g *GameStateProcessor) onRoomPasscodeEvent(sender model.User, event events.RoomPasscodeEvent) error {
+	// Validate the issuer is the room leader
+	if err := g.checkRoomLeader(sender, events.EventTypeRoomPasscode); err != nil {
+		return err
+	}
+
+	if !access.ValidPasscode(event.Passcode) {
+		return events.Reject(events.ErrorInvalidPasscode, "Invalid passcode")
+	}
+
+	g.access.SetPasscode(event.Passcode)
+	g.broadcast(events.RoomPasscodeEvent{User: sender, HasPasscode: g.access.RequiresPasscode()})
+	return nil
+}
+
+func (g *GameStateProcessor) onKickPlayerEvent(sender model.User, event events.KickPlayerEvent) error {
+	return g.removePlayer(sender, event.User.ID, false)
+}
+
+func (g *GameStateProcessor) onBanPlayerEvent(sender model.User, event events.BanPlayerEvent) error {
+	return g.removePlayer(sender, event.User.ID, true)
+}
+
+// removePlayer kicks a player from the room on behalf of the room leader, optionally banning them from rejoining. If
+// the player was the current drawer, the rest of their turn is skipped.
+func (g *GameStateProcessor) removePlayer(sender model.User, targetID string, ban bool) error {
+	eventType := events.EventTypeKickPlayer
+	if ban {
+		eventType = events.EventTypeBanPlayer
+	}
+
+	// Validate the issuer is the room leader
+	if err := g.checkRoomLeader(sender, eventType); err != nil {
+		return err
+	}
+	roomLeaderID := sender.ID
+	if targetID == sender.ID {
+		return events.Reject(events.ErrorInvalidTarget, "The room leader cannot remove themselves")
+	}
+
+	if ban {
+		g.players.Ban(targetID)
+	}
+	g.access.Revoke(targetID)
+
+	player, ok := g.players.RemovePlayer(targetID)
+	if !ok {
+		if ban {
+			// The ban still applies to a user who has already left the room
+			return nil
+		}
+		return events.Reject(events.ErrorInvalidTarget, "The target user is not in the room")
+	}
+	userModel := player.ToUserModel()
+
+	log.Info().
+		Str("roomID", g.roomID).
+		Str("uid", targetID).
+		Bool("ban", ban).
+		Msg("Room leader removed a player from the room")
+
+	if ban {
+		g.broadcast(events.UserBanned(player.ToModel(roomLeaderID)))
+		g.broadcastChat(events.ChatUserBanned(userModel))
+		player.Disconnect(websocket.StatusPolicyViolation, "banned from the room")
+	} else {
+		g.broadcast(events.UserKicked(player.ToModel(roomLeaderID)))
+		g.broadcastChat(events.ChatUserKicked(userModel))
+		player.Disconnect(websocket.StatusPolicyViolation, "kicked from the room")
+	}
+
+	if g.status.Status() == model.GameStarted && g.turn.drawer.ID == targetID {
+		g.onDrawerRemoved()
+	}
+	return nil
+}
+
+func (g *GameStateProcessor) onRoomLeaderEvent(sender model.User, event events.RoomLeaderEvent) error {
+	// Validate the issuer is the room leader
+	if err := g.checkRoomLeader(sender, events.EventTypeRoomLeader); err != nil {
+		return err
+	}
+
+	target, ok := g.players.GetPlayer(event.User.ID)
+	if !ok || target.ID() == sender.ID {
+		return events.Reject(events.ErrorInvalidTarget, "The target user is not another player in the room")
+	}
+
+	if !g.changeRoomLeader(target.ToUserModel()) {
+		return events.Reject(events.ErrorInvalidTarget, "The target user is disconnected")
+	}
+	return nil
+}
+
+// onClockSyncEvent replies to the sender's clock sync ping with the server's current time
+func (g *GameStateProcessor) onClockSyncEvent(sender model.User, event events.ClockSyncEvent) error {
+	g.emitUnsequenced(events.ClockSyncEvent{
+		ClientTime: event.ClientTime,
+		ServerTime: events.UnixMillis(g.now()),
+	}, sender.ID)
+	return nil
 }
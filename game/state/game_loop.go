@@ -2,441 +2,554 @@ package state
 
 import (
 	"errors"
-	"math/rand"
-	"strings"
 	"time"
 
 	"github.com/kvnxiao/pictorio/events"
 	"github.com/kvnxiao/pictorio/game/guess"
 	"github.com/kvnxiao/pictorio/game/hint"
-	"github.com/kvnxiao/pictorio/game/settings"
 	"github.com/kvnxiao/pictorio/model"
 	"github.com/kvnxiao/pictorio/words"
 	"github.com/rs/zerolog/log"
 )
 
-// gameLoop describes the game loop
+// The game loop is a state machine driven by the EventProcessor's goroutine. Each turn moves through the phases below,
+// where every phase is started by a begin* method, and advanced by the phase timer, client events, or connection
+// changes. Since only the EventProcessor's goroutine ever touches the turn state, no locking is needed.
+//
+// Every phase broadcasts its absolute deadline once when it begins, and clients render their own countdown from it by
+// synchronizing their clocks with a ClockSyncEvent. Further updates are only sent when the deadline changes, or when a
+// hint is given during the drawing phase.
 //
 // Game loop summary:
-//   1. Get current turn's player state (the player who is drawing)
-//   -> If current drawer is in the game as a player but is disconnected during this process, skip this player's turn
-//      ^go to 6.
-//   2. Notify which player is next up as the drawer
-//      a. Send TurnNextPlayer event nonce: next player, max time
-//      b. Send countdown for this turn state
-//   3. Begin word selection
-//      -> Drawer has some short duration to choose a randomly generated word
-//      a. Send TurnWordSelection event nonce: current drawer, list of words, max time
-//      b. Send countdown for this turn state
-//   4. Wait for word selection
-//      Either:
-//        -> Drawer selects a word from the list of randomly generated words
-//        -> Drawer waits for timeout
-//   5. Begin drawing for the current drawer
-//      -> Drawer is given a time limit to draw
-//      a. Send TurnDrawing event nonce: current drawer, word lengths, word for the drawer, max time
-//      b. Send countdown for this turn state
-//   6. Wait for guesses or drawer to timeout
-//      -> Hides the chosen word from chat with censored text (asterisks, e.g. '***')
-//   	-> Award points if other players guesses drawing correctly
-//      -> Censors the chat message for players who have already guessed the word correctly
-//      -> Also censors the chat message from the drawer if they try to "cheat" and type out their chosen word
-//   7. Notify end of current turn
-//      -> Sets the next drawer's turn
-//      -> Increments the round counter if the next turn loops back around to the first player
-//      a. Send TurnEnd event nonce: the drawer, the word answer, max time
-//      b. Send countdown for this turn state
-//   8. End game loop if round counter reaches max rounds
-func (g *GameStateProcessor) gameLoop() {
-	// Reset ready for all players since the game has already started
-	g.players.UnreadyAllPlayers()
-
-	// while game is in started state, continue game loop
+//  1. Get current turn's player state (the player who is drawing)
+//     -> If current drawer is in the game as a player but is disconnected during this process, skip this player's turn
+//     ^go to 1. for the next player, ending the game if the rounds are over or every player has been skipped
+//  2. Notify which player is next up as the drawer (phaseNextPlayer)
+//     a. Send TurnNextPlayer event nonce: next player, max time, deadline
+//  3. Begin word selection (phaseWordSelection)
+//     -> Drawer has some short duration to choose a randomly generated word
+//     a. Send TurnWordSelection event nonce: current drawer, list of words, max time, deadline
+//     Either:
+//     -> Drawer selects a word from the list of randomly generated words
+//     -> Drawer waits for timeout, and a word is selected for them
+//     -> Drawer is removed from the room, skipping their turn
+//  4. Begin drawing for the current drawer (phaseDrawing)
+//     -> Drawer is given a time limit to draw
+//     a. Send TurnDrawing event nonce: current drawer, word lengths, word for the drawer, max time, deadline
+//     b. Send an update with the hints whenever a hint is given
+//  5. Wait for guesses or drawer to timeout
+//     -> Hides the chosen word from chat with censored text (asterisks, e.g. '***')
+//     -> Award points if other players guesses drawing correctly
+//     -> Cuts the deadline short on the first correct guess
+//     -> Censors the chat message for players who have already guessed the word correctly
+//     -> Also censors the chat message from the drawer if they try to "cheat" and type out their chosen word
+//     -> Pauses the countdown if the drawer disconnects, ending the turn early if they do not reconnect in time
+//  6. Notify end of current turn (phaseTurnEnd)
+//     -> Sets the next drawer's turn
+//     -> Increments the round counter if the next turn loops back around to the first player
+//     a. Send TurnEnd event nonce: the drawer, the word answer, max time, deadline
+//  7. End game loop if round counter reaches max rounds, otherwise go to 1.
+
+// now returns the current time of the game loop's clock
+func (g *GameStateProcessor) now() time.Time {
+	return g.clock.Now()
+}
+
+// scheduleAt (re)starts the timer which advances the current phase at the given time
+func (g *GameStateProcessor) scheduleAt(at time.Time) {
+	g.stopTimer()
+	g.timer = g.clock.NewTimer(at.Sub(g.now()))
+}
+
+func (g *GameStateProcessor) stopTimer() {
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+}
+
+// timerChan returns the phase timer's channel, or nil if no phase is running so that the select loop never fires it
+func (g *GameStateProcessor) timerChan() <-chan time.Time {
+	if g.timer == nil {
+		return nil
+	}
+	return g.timer.C()
+}
+
+// onTimer advances the current phase once its timer has fired
+func (g *GameStateProcessor) onTimer() {
+	g.timer = nil
+
+	switch g.turn.phase {
+	case phaseNextPlayer:
+		log.Debug().Str("uid", g.turn.drawer.ID).Msg("Next turn is starting")
+
+		// 3. Begin word selection
+		g.beginWordSelection()
+	case phaseWordSelection:
+		g.onWordSelectionTimeout()
+	case phaseDrawing:
+		g.onTurnDrawingTimer()
+	case phaseTurnEnd:
+		g.onTurnEndTimeout()
+	}
+}
+
+// beginPhase sets the deadline of a new phase which lasts for the given number of seconds, and starts its timer
+func (g *GameStateProcessor) beginPhase(phase turnPhase, maxTimeSeconds int) {
+	g.turn.phase = phase
+	g.turn.maxTimeSeconds = maxTimeSeconds
+	g.turn.deadline = g.now().Add(time.Duration(maxTimeSeconds) * time.Second)
+	g.status.SetDeadline(g.turn.deadline)
+	g.scheduleAt(g.turn.deadline)
+}
+
+// beginTurn starts the next turn, skipping the turns of drawers who are disconnected or no longer in the room
+func (g *GameStateProcessor) beginTurn() {
 	for g.status.Status() == model.GameStarted {
 		// Ensure that everyone currently playing is still connected before continuing
 		if g.players.AllPlayersDisconnected() {
 			log.Info().Msg("Seems like no one is left in the room for a game still in progress. Cleaning up.")
+			g.stopGame()
 			g.status.SetStatus(model.GameOver)
 			return
 		}
 
 		log.Debug().Msg("Starting next turn!")
-		setting := g.status.Settings()
 
-		// 1. Send the next drawer, skip if disconnected during this check
+		// 1. Send the next drawer, skip if disconnected or removed from the room
 		userModel, isConnected, err := g.getDrawerPlayer()
-		if err != nil {
-			log.Error().Msg("Failed to get current turn's player state")
+		if err != nil && !errors.Is(err, errDrawerNotInRoom) {
+			log.Error().Err(err).Msg("Failed to get current turn's player state")
+			g.gameOver()
 			return
 		}
-		if !isConnected {
-			if userModel.ID == "" {
-				log.Error().Msg("Current drawer is not connected, but user ID was invalid")
+		if err != nil || !isConnected {
+			// End the game if every player in the turn order has been skipped in a row
+			g.skippedTurns++
+			if g.skippedTurns >= len(g.status.PlayerOrderIDs()) {
+				log.Info().Msg("No player in the turn order is able to draw. Ending game.")
+				g.gameOver()
 				return
 			}
 			log.Debug().Msg("Current drawer not connected! Skipping turn.")
-			g.skipTurn()
+			if g.skipTurn() {
+				return
+			}
 			continue
 		}
+		g.skippedTurns = 0
 
 		// 2. Begin next player turn notification
-		g.beginTurnNextPlayer(userModel, setting)
-
-		// 3. Begin word selection
-		// 4. Wait for word selection
-		generatedWords, maxSelectionTimeSeconds := g.beginWordSelection(userModel, setting)
-		selectedWord := g.waitForSelectedWord(generatedWords, maxSelectionTimeSeconds)
-		word := words.NewGameWord(selectedWord)
-		g.status.SetCurrentWord(word)
-
-		// 5. Begin turn drawing
-		// 6. Wait for player guesses, or timeout from current drawer drawing
-		maxDrawingTimeSeconds := g.beginTurnDrawing(userModel, word, setting)
-		g.waitForGuessOrTimeout(userModel, maxDrawingTimeSeconds, setting)
-
-		// 7. End current turn
-		g.beginTurnEnd(userModel, setting)
-
-		// 8. Check rounds to end game loop
-		if g.checkRounds(setting) {
-			break
-		}
+		g.beginTurnNextPlayer(userModel)
+		return
 	}
-	g.gameOver()
 }
 
+// errDrawerNotInRoom is returned when the current turn's player has been removed from the room
+var errDrawerNotInRoom = errors.New("could not get player state with invalid user id for current turn")
+
 func (g *GameStateProcessor) getDrawerPlayer() (model.User, bool, error) {
 	currentTurnID := g.status.CurrentTurnID()
 	player, ok := g.players.GetPlayer(currentTurnID)
 	if !ok {
-		return model.User{}, false, errors.New("could not get player state with invalid user id for current turn")
+		return model.User{}, false, errDrawerNotInRoom
 	}
 
 	return player.ToUserModel(), player.IsConnected(), nil
 }
 
-func (g *GameStateProcessor) beginTurnNextPlayer(userModel model.User, setting settings.GameSettings) {
+func (g *GameStateProcessor) beginTurnNextPlayer(userModel model.User) {
 	log.Debug().Str("uid", userModel.ID).Msg("Beginning next turn phase for next player")
 	g.status.SetTurnStatus(model.TurnNextPlayer)
 
-	maxTimeSeconds := setting.MaxTurnNextPlayerTimeSeconds
-	g.broadcast(events.TurnBeginNextPlayer(userModel, g.status.CurrentRound(), maxTimeSeconds))
+	maxTimeSeconds := g.status.Settings().MaxTurnNextPlayerTimeSeconds
+	g.turn = turn{drawer: userModel}
+	g.beginPhase(phaseNextPlayer, maxTimeSeconds)
+	g.broadcast(events.TurnBeginNextPlayer(userModel, g.status.CurrentRound(), maxTimeSeconds, g.turn.deadline))
+}
 
-	timeLeftSeconds := maxTimeSeconds
-	timeout := time.After(time.Duration(maxTimeSeconds+1) * time.Second)
-	ticker := time.Tick(1 * time.Second)
-	for {
-		select {
+// beginWordSelection starts the word selection process for the current turn
+func (g *GameStateProcessor) beginWordSelection() {
+	userModel := g.turn.drawer
+	log.Debug().Str("uid", userModel.ID).Msg("Beginning word selection phase for the drawer")
+	g.status.SetTurnStatus(model.TurnSelection)
 
-		case <-timeout:
-			log.Debug().Str("uid", userModel.ID).Msg("Next turn is starting")
-			g.status.SetTimeRemaining(0)
-			g.broadcast(events.TurnNextPlayerCountdown(maxTimeSeconds, 0))
-			return
+	// Generate random word list, preferring words that have not been drawn yet or seen by the players in other rooms
+	generatedWords, exhausted := g.status.GenerateWords(g.seenWords())
+	if len(generatedWords) == 0 {
+		log.Error().Str("roomID", g.roomID).Msg("No words to select from, ending the game")
+		g.gameOver()
+		return
+	}
+	if exhausted && !g.wordsExhausted {
+		g.wordsExhausted = true
+		log.Info().Str("roomID", g.roomID).Msg("Every word has been drawn, words will now repeat")
+		g.broadcastChat(events.ChatSystemEvent("Every word has been drawn, so words will now be repeated."))
+	}
+	maxSelectionTimeSeconds := g.status.Settings().MaxTurnSelectionTimeSeconds
 
-		case <-ticker:
-			timeLeftSeconds -= 1
-			if timeLeftSeconds < 0 {
-				timeLeftSeconds = 0
+	g.turn.wordSelections = generatedWords
+	g.beginPhase(phaseWordSelection, maxSelectionTimeSeconds)
+	deadline := g.turn.deadline
+
+	log.Debug().
+		Str("uid", userModel.ID).
+		Strs("words", generatedWords).
+		Msg("Generated random words for the drawer")
+
+	// Send TurnBeginSelection event to current drawer (with the words)
+	// Send TurnBeginSelection event to the other players (without the words)
+	g.emit(
+		events.TurnBeginSelectionCurrentPlayer(userModel, maxSelectionTimeSeconds, deadline, generatedWords),
+		userModel.ID,
+	)
+	g.broadcastExcluding(events.TurnBeginSelection(userModel, maxSelectionTimeSeconds, deadline), userModel.ID)
+}
+
+// onWordSelectionTimeout auto selects a word for the drawer, since they did not select a word in time
+func (g *GameStateProcessor) onWordSelectionTimeout() {
+	log.Debug().Msg("Word selection timeout")
+	g.selectWord(g.turn.wordSelections[g.rng.Intn(len(g.turn.wordSelections))])
+}
+
+// seenWords returns the words that each user in the room has seen in other rooms
+func (g *GameStateProcessor) seenWords() []map[string]bool {
+	users := g.players.GetConnectedPlayers(true)
+	seen := make([]map[string]bool, len(users))
+	for i, u := range users {
+		seen[i] = words.Histories().Seen(u.ID)
+	}
+	return seen
+}
+
+// selectWord ends the word selection phase with the selected word
+func (g *GameStateProcessor) selectWord(selectedWord string) {
+	word := words.NewGameWord(selectedWord, g.rng)
+	g.status.SetCurrentWord(word)
+
+	// Remember that every user in the room has seen the word, for the rooms they play in later. Custom words are only
+	// known to the room they were uploaded to, and so are not remembered.
+	if !g.status.CustomWords().Contains(word.Word()) {
+		users := g.players.GetConnectedPlayers(true)
+		userIDs := make([]string, len(users))
+		for i, u := range users {
+			userIDs[i] = u.ID
+		}
+		words.Histories().Record(userIDs, word.Word())
+	}
+
+	// 4. Begin turn drawing
+	g.beginTurnDrawing(word)
+}
+
+// beginTurnDrawing starts the turn drawing
+func (g *GameStateProcessor) beginTurnDrawing(word words.GameWord) {
+	userModel := g.turn.drawer
+	log.Debug().Str("uid", userModel.ID).Msg("Beginning drawing phase for the drawer")
+	g.status.SetTurnStatus(model.TurnDrawing)
+
+	setting := g.status.Settings()
+	maxDrawingTimeSeconds := setting.MaxTurnDrawingTimeSeconds
+
+	g.turn.wordSelections = nil
+	g.turn.word = word
+	g.turn.difficulty = words.Default().Difficulty(word.Word())
+	g.turn.guesses = guess.NewPlayerGuesses(userModel, g.players.GetConnectedPlayers(false))
+	g.turn.scorer = guess.NewScorer(setting.Scoring)
+	g.turn.hints = hint.NewHint(word.Hints(), setting.HintSettings)
+	g.turn.hintsSent = make([]model.Hint, 0)
+	g.beginPhase(phaseDrawing, maxDrawingTimeSeconds)
+	g.scheduleTurnDrawing()
+	deadline := g.turn.deadline
+
+	// Send TurnBeginDrawing event to current drawer (with the selected word)
+	// Send TurnBeginDrawing event to the other players (without the selected word)
+	g.emit(
+		events.TurnBeginDrawingCurrentPlayer(userModel, maxDrawingTimeSeconds, deadline, word.WordLength(), word.Word()),
+		userModel.ID,
+	)
+	g.broadcastExcluding(
+		events.TurnBeginDrawing(userModel, maxDrawingTimeSeconds, deadline, word.WordLength()),
+		userModel.ID,
+	)
+
+	// The drawer may have disconnected while selecting a word
+	if drawer, ok := g.players.GetPlayer(userModel.ID); ok && !drawer.IsConnected() {
+		g.pauseTurnDrawing()
+	}
+}
+
+// scheduleTurnDrawing sets the drawing phase's timer to fire at the deadline, or when the next hint is due if that is
+// sooner
+func (g *GameStateProcessor) scheduleTurnDrawing() {
+	t := &g.turn
+	at := t.deadline
+	if !t.firstGuess {
+		if hintTimeLeftSeconds, ok := t.hints.NextHintTime(); ok {
+			hintAt := t.deadline.Add(-time.Duration(hintTimeLeftSeconds) * time.Second)
+			if hintAt.Before(at) {
+				at = hintAt
 			}
+		}
+	}
+	g.scheduleAt(at)
+}
+
+// onTurnDrawingTimer ends the drawing phase once the deadline or the drawer's grace period has passed, and otherwise
+// gives the next hint
+func (g *GameStateProcessor) onTurnDrawingTimer() {
+	t := &g.turn
+
+	if t.paused {
+		log.Debug().Msg("Drawer did not reconnect in time, ending drawing phase")
+		g.endTurnDrawing(events.TurnEndDrawerLeft)
+		return
+	}
+
+	now := g.now()
+	if !now.Before(t.deadline) {
+		log.Debug().Msg("Drawing phase timeout")
+		g.endTurnDrawing(events.TurnEndTimeout)
+		return
+	}
+
+	timeLeftSeconds := events.SecondsLeft(t.deadline, now)
+	if !t.firstGuess {
+		nextHint, hasNextHint := t.hints.NextHint(timeLeftSeconds)
+		if hasNextHint {
 			log.Debug().
-				Str("uid", userModel.ID).
-				Int("timeLeft", timeLeftSeconds).
-				Msg("Next turn timer countdown")
-			g.status.SetTimeRemaining(timeLeftSeconds)
-			g.broadcast(events.TurnNextPlayerCountdown(maxTimeSeconds, timeLeftSeconds))
+				Int("wordIndex", nextHint.WordIndex).
+				Int("charIndex", nextHint.CharIndex).
+				Str("char", string(nextHint.Char)).
+				Msg("Generating next hint")
+			t.hintsSent = append(t.hintsSent, nextHint)
+			g.broadcastUnsequenced(g.turnDrawingUpdate())
 		}
 	}
+	g.scheduleTurnDrawing()
+}
+
+// pauseTurnDrawing pauses the drawing countdown for the room's drawer grace period
+func (g *GameStateProcessor) pauseTurnDrawing() {
+	t := &g.turn
+	if t.paused {
+		return
+	}
+
+	gracePeriodSeconds := g.status.Settings().DrawerGracePeriodSeconds
+	log.Debug().
+		Int("gracePeriod", gracePeriodSeconds).
+		Msg("Drawer disconnected, pausing drawing phase")
+
+	now := g.now()
+	t.paused = true
+	t.remaining = t.deadline.Sub(now)
+	timeLeftSeconds := events.SecondsLeft(t.deadline, now)
+	g.status.SetTimeRemaining(timeLeftSeconds)
+	g.scheduleAt(now.Add(time.Duration(gracePeriodSeconds) * time.Second))
+	g.broadcastUnsequenced(g.turnDrawingUpdate())
 }
 
-func (g *GameStateProcessor) waitForSelectedWord(
-	words []string,
-	maxTimeSeconds int,
-) string {
-	log.Debug().Msg("Waiting for word selection from drawer")
+// resumeTurnDrawing resumes the drawing countdown once the drawer has reconnected within the grace period, moving the
+// deadline back by the time spent paused
+func (g *GameStateProcessor) resumeTurnDrawing() {
+	t := &g.turn
+	if !t.paused {
+		return
+	}
 
-	timeLeftSeconds := maxTimeSeconds
-	timeout := time.After(time.Duration(maxTimeSeconds+1) * time.Second)
-	ticker := time.Tick(1 * time.Second)
+	log.Debug().Msg("Drawer reconnected, resuming drawing phase")
+	now := g.now()
+	t.paused = false
+	t.deadline = now.Add(t.remaining)
+	g.status.SetDeadline(t.deadline)
+	g.scheduleTurnDrawing()
+	g.broadcastUnsequenced(g.turnDrawingUpdate())
+}
 
-	var selectedWord string
+// turnDrawingUpdate returns the update of the drawing phase's countdown and hints, which supersedes any previous update
+func (g *GameStateProcessor) turnDrawingUpdate() events.TurnDrawingEvent {
+	t := &g.turn
+	now := g.now()
+	if t.paused {
+		return events.TurnDrawingPaused(t.maxTimeSeconds, events.SecondsLeft(now.Add(t.remaining), now), t.hintsSent)
+	}
+	return events.TurnDrawingUpdate(t.maxTimeSeconds, events.SecondsLeft(t.deadline, now), t.deadline, t.hintsSent)
+}
 
-	startTime := time.Now().UnixNano()
-	for {
-		select {
+// drawingTimeLeft returns the time left to guess the word, which stands still while the drawing phase is paused
+func (g *GameStateProcessor) drawingTimeLeft() time.Duration {
+	t := &g.turn
+	timeLeft := t.remaining
+	if !t.paused {
+		timeLeft = t.deadline.Sub(g.now())
+	}
+	if timeLeft < 0 {
+		return 0
+	}
+	return timeLeft
+}
 
-		// Player did not select a word in time, auto select a word for them
-		case <-timeout:
-			log.Debug().
-				Msg("Word selection timeout")
+// endTurnDrawing ends the drawing phase, and moves on to the end of the turn
+func (g *GameStateProcessor) endTurnDrawing(reason events.TurnEndReason) {
+	// 6. End current turn
+	g.beginTurnEnd(reason)
+}
 
-			g.status.SetTimeRemaining(0)
-			selectedWord = words[rand.Intn(len(words))]
-			return selectedWord
+// onGuess handles a chat message sent during the drawing phase as a guess of the word
+func (g *GameStateProcessor) onGuess(sender model.User, message string) {
+	t := &g.turn
+	if !g.handleGuess(sender, message) {
+		return
+	}
 
-		// Send players a decrementing TurnWordSelection event
-		case <-ticker:
-			timeLeftSeconds -= 1
-			if timeLeftSeconds < 0 {
-				timeLeftSeconds = 0
-			}
+	if t.guesses.FinishedGuessing() {
+		log.Debug().Msg("Everyone has guessed the word")
+		g.endTurnDrawing(events.TurnEndAllGuessed)
+		return
+	}
 
-			log.Debug().
-				Int("timeLeft", timeLeftSeconds).
-				Msg("Word selection timer countdown")
-
-			g.status.SetTimeRemaining(timeLeftSeconds)
-			g.broadcast(events.TurnWordSelectionCountdown(maxTimeSeconds, timeLeftSeconds))
-
-		case selectionIndex := <-g.wordSelectionIndex:
-			// Ignore elements from selection index channel if the timestamp is before when startTime was calculated
-			if selectionIndex.Timestamp >= startTime {
-				if selectionIndex.Value >= len(words) {
-					log.Error().
-						Msg("Word selection index out of bounds, exceeds the number of generated random words")
-				} else {
-					selectedWord = words[selectionIndex.Value]
-					log.Debug().
-						Str("selectedWord", selectedWord).
-						Msg("A word has been selected by the drawer")
-					return selectedWord
-				}
-			}
+	cutSeconds := g.status.Settings().MaxTurnDrawingTimeCutSeconds
+	now := g.now()
+	cutDeadline := now.Add(time.Duration(cutSeconds) * time.Second)
+	if t.deadline.After(cutDeadline) {
+		log.Debug().Msg("First guess of the word, cutting the deadline short")
+
+		t.firstGuess = true
+		t.deadline = cutDeadline
+		if t.paused {
+			t.remaining = cutDeadline.Sub(now)
+			g.status.SetTimeRemaining(cutSeconds)
+		} else {
+			g.status.SetDeadline(t.deadline)
+			g.scheduleTurnDrawing()
 		}
+		g.broadcastUnsequenced(g.turnDrawingUpdate())
 	}
 }
 
-func (g *GameStateProcessor) handleGuess(
-	currentTurnUser model.User,
-	word words.GameWord,
-	guesses *guess.PlayerGuesses,
-	wordGuess Guess,
-) bool {
-	candidate := strings.ToLower(strings.TrimSpace(wordGuess.Value))
+// handleGuess checks a guess against the word being drawn, returning true if the sender guessed the word correctly
+// for the first time
+func (g *GameStateProcessor) handleGuess(sender model.User, message string) bool {
+	drawerID := g.turn.drawer.ID
+	word := g.turn.word
+	guesses := g.turn.guesses
 
 	// Handle word match
-	if word.Word() == candidate || strings.HasPrefix(candidate, word.Word()) {
-		if currentTurnUser.ID == wordGuess.User.ID || guesses.HasGuessed(wordGuess.User.ID) {
+	if word.IsGuessedBy(message) {
+		if drawerID == sender.ID || guesses.HasGuessed(sender.ID) {
 			// Send censored word if user has already guessed the word, or the drawer is trying to send the word
-			g.broadcastChat(events.ChatUserMessage(wordGuess.User, word.Censored()))
+			g.broadcastChat(events.ChatUserMessage(sender, word.Censored()))
 			return false
 		}
 
 		// First time the user is guessing the word correctly
-		guesser, ok := g.players.GetPlayer(wordGuess.User.ID)
+		guesser, ok := g.players.GetPlayer(sender.ID)
 		if !ok {
 			log.Error().Msg("Player guessed the word correctly but does not exist in the players list")
 			return false
 		}
-		drawer, _ := g.players.GetPlayer(currentTurnUser.ID)
-		guesserPoints, drawerPoints := guesses.AddGuessed(wordGuess.User.ID)
-		g.awardPoints(guesser, guesserPoints, drawer, drawerPoints)
-		g.broadcastChat(events.ChatUserGuessed(wordGuess.User))
+		drawer, ok := g.players.GetPlayer(drawerID)
+		if !ok {
+			log.Error().Msg("Player guessed the word correctly but the drawer does not exist in the players list")
+			return false
+		}
+		award := g.turn.scorer.Score(guess.Guess{
+			Rank:       guesses.AddGuessed(sender.ID),
+			Guessers:   guesses.Guessers(),
+			TimeLeft:   g.drawingTimeLeft(),
+			TotalTime:  time.Duration(g.turn.maxTimeSeconds) * time.Second,
+			Difficulty: g.turn.difficulty,
+		})
+		g.awardPoints(guesser, drawer, award)
+		g.broadcastChat(events.ChatUserGuessed(sender))
 		return true
 	}
 
 	// Handle non-exact-match messages
-	if strings.Contains(candidate, word.Word()) &&
-		(currentTurnUser.ID == wordGuess.User.ID || guesses.HasGuessed(wordGuess.User.ID)) {
+	if word.IsContainedIn(message) && (drawerID == sender.ID || guesses.HasGuessed(sender.ID)) {
 		// Censor text that contains the word as a substring
-		g.broadcastChat(events.ChatUserMessage(wordGuess.User, words.Censor(len(wordGuess.Value))))
+		g.broadcastChat(events.ChatUserMessage(sender, words.Censor(words.Length(message))))
 	} else {
 		// Regular chat messages
-		g.broadcastChat(events.ChatUserMessage(wordGuess.User, wordGuess.Value))
+		g.broadcastChat(events.ChatUserMessage(sender, message))
 	}
 	return false
 }
 
-func (g *GameStateProcessor) waitForGuessOrTimeout(
-	currentTurnUser model.User,
-	maxTimeSeconds int,
-	setting settings.GameSettings,
-) {
-	log.Debug().Msg("Waiting for guess or timeout for the drawing phase")
-
-	currentWord := g.status.CurrentWord()
-
-	// Guess
-	guesses := guess.NewPlayerGuesses(currentTurnUser, g.players.GetConnectedPlayers(false))
-	firstGuess := false
-
-	// Hints
-	hints := hint.NewHint(currentWord.Hints(), setting.HintSettings)
-	var hintsToSend = make([]model.Hint, 0)
-
-	// Timer
-	timeLeftSeconds := maxTimeSeconds
-	timeout := time.After(time.Duration(maxTimeSeconds+1) * time.Second)
-	ticker := time.Tick(1 * time.Second)
-
-	startTime := time.Now().UnixNano()
-	for {
-		select {
-
-		// Turn ended
-		case <-timeout:
-			log.Debug().Msg("Drawing phase timeout")
-
-			g.status.SetTimeRemaining(0)
-			g.broadcast(events.TurnDrawingCountdown(maxTimeSeconds, 0, hintsToSend))
-			return
-
-		// Send players a decrementing TurnDrawing event
-		case <-ticker:
-			timeLeftSeconds -= 1
-			if timeLeftSeconds < 0 {
-				timeLeftSeconds = 0
-			}
-
-			log.Debug().
-				Int("timeLeft", timeLeftSeconds).
-				Msg("Drawing phase timer countdown")
-
-			g.status.SetTimeRemaining(timeLeftSeconds)
-
-			if !firstGuess {
-				nextHint, hasNextHint := hints.NextHint(timeLeftSeconds)
-				if hasNextHint {
-					log.Debug().
-						Int("wordIndex", nextHint.WordIndex).
-						Int("charIndex", nextHint.CharIndex).
-						Str("char", string(nextHint.Char)).
-						Msg("Generating next hint")
-					hintsToSend = append(hintsToSend, nextHint)
-				}
-			}
-			g.broadcast(events.TurnDrawingCountdown(maxTimeSeconds, timeLeftSeconds, hintsToSend))
-			if timeLeftSeconds == 0 {
-				return
-			}
-
-		case wordGuess := <-g.wordGuess:
-			// Ignore elements from word guess channel if the timestamp is before when startTime was calculated
-			if wordGuess.Timestamp >= startTime {
-				if g.handleGuess(currentTurnUser, currentWord, guesses, wordGuess) {
-					if guesses.FinishedGuessing() {
-						log.Debug().Msg("Everyone has guessed the word")
-
-						timeLeftSeconds = 0
-						g.status.SetTimeRemaining(0)
-						g.broadcast(events.TurnDrawingCountdown(maxTimeSeconds, timeLeftSeconds, hintsToSend))
-						return
-					} else if timeLeftSeconds > setting.MaxTurnDrawingTimeCutSeconds {
-						log.Debug().Msg("First guess of the word, reducing countdown timer")
-
-						firstGuess = true
-						timeLeftSeconds = setting.MaxTurnDrawingTimeCutSeconds
-						g.status.SetTimeRemaining(timeLeftSeconds)
-						g.broadcast(events.TurnDrawingCountdown(maxTimeSeconds, timeLeftSeconds, hintsToSend))
-					}
-				}
-			}
+// onDrawerRemoved handles the current drawer being removed from the room, by skipping the rest of their turn
+func (g *GameStateProcessor) onDrawerRemoved() {
+	switch g.turn.phase {
+	case phaseNextPlayer, phaseWordSelection:
+		log.Debug().Msg("Current drawer left the room before drawing! Skipping turn.")
+		if !g.skipTurn() {
+			g.beginTurn()
 		}
+	case phaseDrawing:
+		log.Debug().Msg("Drawer was removed from the room, ending drawing phase")
+		g.endTurnDrawing(events.TurnEndDrawerLeft)
 	}
 }
 
-// beginWordSelection starts the word selection process for the current turn
-func (g *GameStateProcessor) beginWordSelection(userModel model.User, setting settings.GameSettings) ([]string, int) {
-	log.Debug().Str("uid", userModel.ID).Msg("Beginning word selection phase for the drawer")
-	g.status.SetTurnStatus(model.TurnSelection)
-
-	// Generate random word list (words that have not been recorded yet)
-	generatedWords := g.status.GenerateWords()
-	maxSelectionTimeSeconds := setting.MaxTurnSelectionTimeSeconds
-	g.status.SetTimeRemaining(maxSelectionTimeSeconds)
-
-	log.Debug().
-		Str("uid", userModel.ID).
-		Strs("words", generatedWords).
-		Msg("Generated random words for the drawer")
-
-	// Send TurnBeginSelection event to current drawer (with the words)
-	// Send TurnBeginSelection event to the other players (without the words)
-	g.emit(events.TurnBeginSelectionCurrentPlayer(userModel, maxSelectionTimeSeconds, generatedWords), userModel.ID)
-	g.broadcastExcluding(events.TurnBeginSelection(userModel, maxSelectionTimeSeconds), userModel.ID)
-
-	return generatedWords, maxSelectionTimeSeconds
-}
-
-// beginTurnDrawing starts the turn drawing
-func (g *GameStateProcessor) beginTurnDrawing(userModel model.User, word words.GameWord, setting settings.GameSettings) int {
-	log.Debug().Str("uid", userModel.ID).Msg("Beginning drawing phase for the drawer")
-	g.status.SetTurnStatus(model.TurnDrawing)
-
-	maxDrawingTimeSeconds := setting.MaxTurnDrawingTimeSeconds
-	g.status.SetTimeRemaining(maxDrawingTimeSeconds)
-
-	// Send TurnBeginDrawing event to current drawer (with the selected word)
-	// Send TurnBeginDrawing event to the other players (without the selected word)
-	g.emit(
-		events.TurnBeginDrawingCurrentPlayer(userModel, maxDrawingTimeSeconds, word.WordLength(), word.Word()),
-		userModel.ID,
-	)
-	g.broadcastExcluding(events.TurnBeginDrawing(userModel, maxDrawingTimeSeconds, word.WordLength()), userModel.ID)
-
-	return maxDrawingTimeSeconds
-}
-
-func (g *GameStateProcessor) skipTurn() {
+// skipTurn moves on to the next drawer's turn, returning true if the game ended because the rounds are over
+func (g *GameStateProcessor) skipTurn() bool {
 	g.status.IncrementNextTurn()
+	if g.checkRounds() {
+		g.gameOver()
+		return true
+	}
+	return false
 }
 
-func (g *GameStateProcessor) beginTurnEnd(userModel model.User, setting settings.GameSettings) {
+func (g *GameStateProcessor) beginTurnEnd(reason events.TurnEndReason) {
+	userModel := g.turn.drawer
 	log.Debug().Str("uid", userModel.ID).Msg("Beginning turn end phase for the drawer")
 
 	g.status.SetTurnStatus(model.TurnEnded)
 	word := g.status.CurrentWord().Word()
 
-	// Notify that the current drawer's turn is ending, and broadcast what the word was
-	maxTimeSeconds := setting.MaxTurnEndTimeSeconds
-	g.broadcast(events.TurnBeginEnd(userModel, word, maxTimeSeconds))
-
-	timeLeftSeconds := maxTimeSeconds
-	timeout := time.After(time.Duration(maxTimeSeconds+1) * time.Second)
-	ticker := time.Tick(1 * time.Second)
-	for {
-		select {
-
-		case <-timeout:
-			log.Debug().Msg("Turn end phase timeout")
+	maxTimeSeconds := g.status.Settings().MaxTurnEndTimeSeconds
+	g.turn.paused = false
+	g.beginPhase(phaseTurnEnd, maxTimeSeconds)
 
-			g.status.SetTimeRemaining(0)
-			g.broadcast(events.TurnEndCountdown(maxTimeSeconds, 0))
-
-			// Clear drawing state
-			g.drawingHistory.Clear()
-
-			// Increment current turn to the next user,
-			// this will also will increment the round counter if the next turn loops back to first player
-			g.status.IncrementNextTurn()
-
-			return
+	// Notify that the current drawer's turn is ending, and broadcast what the word was
+	g.broadcast(events.TurnBeginEnd(userModel, word, maxTimeSeconds, g.turn.deadline, reason))
+}
 
-		case <-ticker:
-			timeLeftSeconds -= 1
-			if timeLeftSeconds <= 0 {
-				timeLeftSeconds = 0
-			}
+func (g *GameStateProcessor) onTurnEndTimeout() {
+	log.Debug().Msg("Turn end phase timeout")
 
-			log.Debug().Int("timeLeft", timeLeftSeconds).Msg("Turn end timer countdown")
+	// Clear drawing state
+	g.drawingHistory.Reset()
 
-			g.status.SetTimeRemaining(timeLeftSeconds)
-			g.broadcast(events.TurnEndCountdown(maxTimeSeconds, timeLeftSeconds))
-		}
+	// Increment current turn to the next user,
+	// this will also will increment the round counter if the next turn loops back to first player
+	// 7. Check rounds to end game loop
+	if g.skipTurn() {
+		return
 	}
+	g.beginTurn()
 }
 
 // checkRounds returns a boolean of whether the rounds played has exceeded the maximum number of rounds to be played
-func (g *GameStateProcessor) checkRounds(setting settings.GameSettings) bool {
+func (g *GameStateProcessor) checkRounds() bool {
 	log.Debug().Int("round", g.status.CurrentRound()).Msg("Checking current round")
-	if g.status.CurrentRound() >= setting.MaxRounds {
+	if g.status.CurrentRound() >= g.status.Settings().MaxRounds {
 		return true
 	}
 	return false
 }
 
+// stopGame stops the phase timer and resets the turn state
+func (g *GameStateProcessor) stopGame() {
+	g.stopTimer()
+	g.turn = turn{}
+}
+
 func (g *GameStateProcessor) gameOver() {
 	log.Debug().Msg("Game over!")
+	g.stopGame()
 
 	winners := g.players.Winners()
 	g.status.SetWinners(winners)
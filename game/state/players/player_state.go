@@ -1,8 +1,13 @@
 package players
 
 import (
+	"time"
+
+	"github.com/kvnxiao/pictorio/codec"
 	"github.com/kvnxiao/pictorio/game/user"
 	"github.com/kvnxiao/pictorio/model"
+	"github.com/rs/zerolog/log"
+	"nhooyr.io/websocket"
 )
 
 type PlayerState interface {
@@ -20,12 +25,16 @@ type PlayerState interface {
 	IsConnected() bool
 	IsReady() bool
 	IsRoomLeader(roomLeaderUserID string) bool
+	ConnectedAt() time.Time
 
 	SetNewConnection(u *user.User)
+	IsConnection(u *user.User) bool
 	SetConnected(connected bool)
 	SetReady(ready bool)
 
-	SendMessage(bytes []byte)
+	SendMessage(bytes []byte, coalesceKey string)
+	Codec() codec.Codec
+	Disconnect(code websocket.StatusCode, reason string)
 
 	ToModel(roomLeaderUserID string) model.PlayerState
 	ToUserModel() model.User
@@ -38,6 +47,7 @@ type Player struct {
 	isSpectator bool
 	isConnected bool
 	isReady     bool
+	connectedAt time.Time
 }
 
 func newPlayer(u *user.User, isSpectator bool) PlayerState {
@@ -99,7 +109,20 @@ func (p *Player) SetNewConnection(u *user.User) {
 	p.user = u
 }
 
+// IsConnection checks whether the given user connection is the player's current connection
+func (p *Player) IsConnection(u *user.User) bool {
+	return p.user == u
+}
+
+// ConnectedAt returns the time at which the player's current connection was established
+func (p *Player) ConnectedAt() time.Time {
+	return p.connectedAt
+}
+
 func (p *Player) SetConnected(connected bool) {
+	if connected && !p.isConnected {
+		p.connectedAt = time.Now()
+	}
 	p.isConnected = connected
 }
 
@@ -107,8 +130,25 @@ func (p *Player) SetReady(ready bool) {
 	p.isReady = ready
 }
 
-func (p *Player) SendMessage(bytes []byte) {
-	p.user.Outgoing() <- bytes
+// SendMessage queues a message to the player's current connection without blocking
+func (p *Player) SendMessage(bytes []byte, coalesceKey string) {
+	p.user.Send(bytes, coalesceKey)
+}
+
+// Codec returns the codec of the player's current connection, which messages sent to the player must be encoded with
+func (p *Player) Codec() codec.Codec {
+	return p.user.Codec()
+}
+
+// Disconnect closes the player's current WebSocket connection in the background, since closing waits for the client to
+// acknowledge the close handshake.
+func (p *Player) Disconnect(code websocket.StatusCode, reason string) {
+	u := p.user
+	go func() {
+		if err := u.Close(code, reason); err != nil {
+			log.Debug().Err(err).Str("uid", u.ID).Msg("Error while closing player connection")
+		}
+	}()
 }
 
 func (p *Player) ToModel(roomLeaderUserID string) model.PlayerState {
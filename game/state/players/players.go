@@ -4,6 +4,7 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/kvnxiao/pictorio/codec"
 	"github.com/kvnxiao/pictorio/events"
 	"github.com/kvnxiao/pictorio/game/user"
 	"github.com/kvnxiao/pictorio/model"
@@ -14,22 +15,31 @@ type Players interface {
 	Summary() model.PlayersSummary
 
 	MaxPlayers() int
+	SetMaxPlayers(maxPlayers int)
+	PlayerCount() int
 	RoomLeaderID() string
+	RoomLeaderName() string
+	SetRoomLeader(userID string) bool
+	NextRoomLeader() (PlayerState, bool)
 
 	GetPlayer(userID string) (PlayerState, bool)
 	GetConnectedPlayers(includeSpectator bool) []model.User
 
-	ReadyPlayer(userID string, ready bool) bool
+	ReadyPlayer(userID string, ready bool) (bool, bool)
+	AwardPoints(userID string, points int) bool
 	UnreadyAllPlayers()
 	AllPlayersReady() ([]string, bool)
 	AllPlayersDisconnected() bool
 
-	SaveConnection(u *user.User, isGameStarted bool) PlayerState
-	RemoveConnection(userID string) PlayerState
+	SaveConnection(u *user.User, isGameStarted bool) (PlayerState, bool)
+	RemoveConnection(u *user.User) PlayerState
+	RemovePlayer(userID string) (PlayerState, bool)
 
-	SendEventToAll(event events.SerializableEvent)
-	SendEventToAllExcept(event events.SerializableEvent, userID string)
-	SendEventToUser(event events.SerializableEvent, userID string)
+	Ban(userID string)
+
+	SendEventToAll(event events.SerializableEvent, seq uint64)
+	SendEventToAllExcept(event events.SerializableEvent, seq uint64, userID string)
+	SendEventToUser(event events.SerializableEvent, seq uint64, userID string)
 
 	Winners() []model.Winner
 
@@ -42,6 +52,7 @@ type PlayerStatesMap struct {
 	maxPlayers   int
 	players      map[string]PlayerState
 	roomLeaderID string
+	banned       map[string]struct{}
 }
 
 func NewPlayerContainer(maxPlayers int) Players {
@@ -49,6 +60,7 @@ func NewPlayerContainer(maxPlayers int) Players {
 		maxPlayers:   maxPlayers,
 		players:      make(map[string]PlayerState),
 		roomLeaderID: "",
+		banned:       make(map[string]struct{}),
 	}
 }
 
@@ -68,9 +80,38 @@ func (s *PlayerStatesMap) Summary() model.PlayersSummary {
 }
 
 func (s *PlayerStatesMap) MaxPlayers() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.maxPlayers
 }
 
+func (s *PlayerStatesMap) SetMaxPlayers(maxPlayers int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxPlayers = maxPlayers
+}
+
+// PlayerCount returns the number of non-spectator players in the room
+func (s *PlayerStatesMap) PlayerCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.playerCount()
+}
+
+// playerCount counts the non-spectator players, and must be called while holding the lock
+func (s *PlayerStatesMap) playerCount() int {
+	count := 0
+	for _, player := range s.players {
+		if !player.IsSpectator() {
+			count++
+		}
+	}
+	return count
+}
+
 func (s *PlayerStatesMap) RoomLeaderID() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -78,6 +119,57 @@ func (s *PlayerStatesMap) RoomLeaderID() string {
 	return s.roomLeaderID
 }
 
+// RoomLeaderName returns the name of the room leader, or an empty string if the room has no leader
+func (s *PlayerStatesMap) RoomLeaderName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	leader, ok := s.players[s.roomLeaderID]
+	if !ok {
+		return ""
+	}
+	return leader.Name()
+}
+
+// SetRoomLeader makes a connected player the room leader, returning false if the user is not a connected player. An
+// empty user ID clears the room leader, in which case the next user to connect becomes the room leader.
+func (s *PlayerStatesMap) SetRoomLeader(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if userID == "" {
+		s.roomLeaderID = ""
+		return true
+	}
+
+	player, ok := s.players[userID]
+	if !ok || !player.IsConnected() {
+		return false
+	}
+	s.roomLeaderID = userID
+	return true
+}
+
+// NextRoomLeader finds the connected user who has been connected for the longest time, other than the current room
+// leader, to succeed the room leader. Players are preferred over spectators.
+func (s *PlayerStatesMap) NextRoomLeader() (PlayerState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var successor PlayerState
+	for _, player := range s.players {
+		if !player.IsConnected() || player.ID() == s.roomLeaderID {
+			continue
+		}
+		if successor == nil ||
+			successor.IsSpectator() && !player.IsSpectator() ||
+			successor.IsSpectator() == player.IsSpectator() && player.ConnectedAt().Before(successor.ConnectedAt()) {
+			successor = player
+		}
+	}
+	return successor, successor != nil
+}
+
 func (s *PlayerStatesMap) GetPlayer(userID string) (PlayerState, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -102,17 +194,35 @@ func (s *PlayerStatesMap) GetConnectedPlayers(includeSpectator bool) []model.Use
 	return connectedUsers
 }
 
-func (s *PlayerStatesMap) ReadyPlayer(userID string, ready bool) bool {
+// ReadyPlayer sets the ready state of a player, returning the new ready state and whether the player exists
+func (s *PlayerStatesMap) ReadyPlayer(userID string, ready bool) (bool, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	player, ok := s.players[userID]
 	if !ok {
 		log.Error().Msg("Attempted to change ready state on an invalid user ID")
+		return false, false
 	}
 
 	player.SetReady(ready)
-	return ready
+	return ready, true
+}
+
+// AwardPoints adds points to a player's score, returning false if the player does not exist. Scores are only changed
+// while holding the write lock, since summaries of the room read them from other goroutines.
+func (s *PlayerStatesMap) AwardPoints(userID string, points int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player, ok := s.players[userID]
+	if !ok {
+		log.Error().Msg("Attempted to award points to an invalid user ID")
+		return false
+	}
+
+	player.AwardPoints(points)
+	return true
 }
 
 func (s *PlayerStatesMap) UnreadyAllPlayers() {
@@ -152,10 +262,15 @@ func (s *PlayerStatesMap) AllPlayersDisconnected() bool {
 	return true
 }
 
-func (s *PlayerStatesMap) SaveConnection(u *user.User, isGameStarted bool) PlayerState {
+// SaveConnection saves a user's new connection to the room, returning false if the user has been banned from the room.
+func (s *PlayerStatesMap) SaveConnection(u *user.User, isGameStarted bool) (PlayerState, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, banned := s.banned[u.ID]; banned {
+		return nil, false
+	}
+
 	// Set the first user as the room leader
 	if s.roomLeaderID == "" {
 		s.roomLeaderID = u.ID
@@ -167,20 +282,22 @@ func (s *PlayerStatesMap) SaveConnection(u *user.User, isGameStarted bool) Playe
 		player.SetNewConnection(u)
 	} else {
 		// New player has joined the room
-		isSpectator := len(s.players) >= s.maxPlayers || isGameStarted
+		isSpectator := s.playerCount() >= s.maxPlayers || isGameStarted
 		player = newPlayer(u, isSpectator)
 		s.players[u.ID] = player
 	}
 	player.SetConnected(true)
-	return player
+	return player, true
 }
 
-func (s *PlayerStatesMap) RemoveConnection(userID string) PlayerState {
+// RemoveConnection marks a user as disconnected once their connection has closed. Nil is returned if the user is no
+// longer in the room, or if the user has since reconnected over a newer connection.
+func (s *PlayerStatesMap) RemoveConnection(u *user.User) PlayerState {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	player, ok := s.players[userID]
-	if !ok {
+	player, ok := s.players[u.ID]
+	if !ok || !player.IsConnection(u) {
 		return nil
 	}
 
@@ -190,34 +307,59 @@ func (s *PlayerStatesMap) RemoveConnection(userID string) PlayerState {
 	return player
 }
 
-func (s *PlayerStatesMap) SendEventToAll(event events.SerializableEvent) {
-	eventBytes := events.ToJson(event)
+// RemovePlayer removes a player from the room entirely, such that they will rejoin as a new player if they reconnect.
+func (s *PlayerStatesMap) RemovePlayer(userID string) (PlayerState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player, ok := s.players[userID]
+	if !ok {
+		return nil, false
+	}
+	delete(s.players, userID)
+	return player, true
+}
+
+// Ban prevents a user from connecting to the room again.
+func (s *PlayerStatesMap) Ban(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.banned[userID] = struct{}{}
+}
+
+// SendEventToAll sends an event to every connected player, encoding the event once for each codec in use
+func (s *PlayerStatesMap) SendEventToAll(event events.SerializableEvent, seq uint64) {
+	encoded := codec.NewEncoded(event, seq)
+	coalesceKey := events.CoalesceKey(event, seq)
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, player := range s.players {
 		if player.IsConnected() {
-			player.SendMessage(eventBytes)
+			player.SendMessage(encoded.Bytes(player.Codec()), coalesceKey)
 		}
 	}
 }
 
-func (s *PlayerStatesMap) SendEventToAllExcept(event events.SerializableEvent, userID string) {
-	eventBytes := events.ToJson(event)
+func (s *PlayerStatesMap) SendEventToAllExcept(event events.SerializableEvent, seq uint64, userID string) {
+	encoded := codec.NewEncoded(event, seq)
+	coalesceKey := events.CoalesceKey(event, seq)
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, player := range s.players {
 		if player.IsConnected() && player.ID() != userID {
-			player.SendMessage(eventBytes)
+			player.SendMessage(encoded.Bytes(player.Codec()), coalesceKey)
 		}
 	}
 }
 
-func (s *PlayerStatesMap) SendEventToUser(event events.SerializableEvent, userID string) {
-	eventBytes := events.ToJson(event)
+func (s *PlayerStatesMap) SendEventToUser(event events.SerializableEvent, seq uint64, userID string) {
+	encoded := codec.NewEncoded(event, seq)
+	coalesceKey := events.CoalesceKey(event, seq)
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -225,9 +367,10 @@ func (s *PlayerStatesMap) SendEventToUser(event events.SerializableEvent, userID
 	player, ok := s.players[userID]
 	if !ok {
 		log.Error().Msg("Attempted to send an event to an invalid player ID")
+		return
 	}
 	if player.IsConnected() {
-		player.SendMessage(eventBytes)
+		player.SendMessage(encoded.Bytes(player.Codec()), coalesceKey)
 	}
 }
 
@@ -2,17 +2,25 @@ package state
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"math/rand"
+	"time"
 
+	"github.com/kvnxiao/pictorio/clock"
+	"github.com/kvnxiao/pictorio/codec"
+	"github.com/kvnxiao/pictorio/ctxs"
 	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/game/guess"
 	"github.com/kvnxiao/pictorio/game/settings"
+	"github.com/kvnxiao/pictorio/game/state/access"
 	"github.com/kvnxiao/pictorio/game/state/chat"
 	"github.com/kvnxiao/pictorio/game/state/drawing"
 	"github.com/kvnxiao/pictorio/game/state/players"
+	"github.com/kvnxiao/pictorio/game/state/replay"
 	"github.com/kvnxiao/pictorio/game/state/status"
 	"github.com/kvnxiao/pictorio/game/user"
 	"github.com/kvnxiao/pictorio/model"
+	"github.com/kvnxiao/pictorio/words"
 	"github.com/rs/zerolog/log"
 )
 
@@ -23,10 +31,52 @@ type GameState interface {
 	// Status gets the Status of the current game
 	Status() model.GameStatus
 
-	StartGame() bool
+	// Access gets the access control guarding who may connect to the room
+	Access() access.Control
 
-	HandleUserConnection(ctx context.Context, user *user.User, connErrChan chan error)
-	RemoveUserConnection(userID string)
+	// Listing summarizes the room for the public lobby browser
+	Listing() model.RoomListing
+	// RoomSummary summarizes the room for clients that have not joined it
+	RoomSummary() model.RoomSummary
+
+	HandleUserConnection(ctx context.Context, user *user.User, connErrChan chan error) error
+	RemoveUserConnection(user *user.User)
+
+	// HandleEvent handles an event sent by a user of the room outside of their WebSocket connection
+	HandleEvent(userID string, event events.GameEvent) error
+}
+
+// ErrBanned is returned when a user who has been banned from the room attempts to connect to it
+var ErrBanned = errors.New("user is banned from the room")
+
+// ErrRoomClosed is returned when a user attempts to connect to a room whose game state has already been cleaned up
+var ErrRoomClosed = errors.New("room is closed")
+
+// joinRequest asks the EventProcessor to add a user's new connection to the room
+type joinRequest struct {
+	ctx         context.Context
+	user        *user.User
+	connErrChan chan error
+	result      chan error
+}
+
+// eventRequest asks the EventProcessor to handle an event sent by a user outside of their WebSocket connection
+type eventRequest struct {
+	userID string
+	event  events.GameEvent
+	result chan error
+}
+
+// Options configure a new GameStateProcessor
+type Options struct {
+	// Passcode is required to join the room if non-empty
+	Passcode string
+
+	// Clock drives the game loop's timers, and defaults to the real clock
+	Clock clock.Clock
+
+	// Seed seeds the room's source of randomness, so that games can be reproduced
+	Seed int64
 }
 
 // GameStateProcessor handles the state of the game
@@ -34,160 +84,128 @@ type GameStateProcessor struct {
 	// roomID is the room ID associated with this game state
 	roomID string
 
+	// clock drives the game loop's timers
+	clock clock.Clock
+
+	// rng is the room's source of randomness, such as for the turn order and word selection
+	rng *rand.Rand
+
 	// status is the current Status of the game
 	status status.GameStatus
 
+	// access guards who may connect to the room
+	access access.Control
+
 	// players represents the userID -> player states mapping
 	players players.Players
 
+	// wordsExhausted is true once every word that the room draws from has been drawn, and words are being repeated
+	wordsExhausted bool
+
 	// drawingHistory is the current drawing history of the game
 	drawingHistory drawing.History
 
 	// chatHistory is the chat history since the beginning of the game
 	chatHistory chat.History
 
+	// replay numbers the events sent to the room, and keeps the most recent events for reconnecting users
+	replay replay.Buffer
+
 	// cleanedUpChan represents whether or not the game state has been cleaned up for the current room
 	cleanedUpChan chan bool
 
 	// messageQueue represents the message queue for events incoming from individual user WebSockets, which will be
 	// processed by the EventProcessor method to handle events
-	messageQueue chan []byte
+	messageQueue chan user.Message
+
+	// joins and leaves hand connection changes over to the EventProcessor, so that the room is only ever mutated from
+	// the EventProcessor's goroutine
+	joins  chan joinRequest
+	leaves chan *user.User
+	// requests hands events sent by users outside of their connections, such as through the REST API, over to the
+	// EventProcessor
+	requests chan eventRequest
+
+	// done is closed once the EventProcessor has stopped running
+	done chan struct{}
 
-	// wordSelectionIndex allows the current turn player to send a TurnSelectionEvent which will be recorded by the
-	// game processor
-	wordSelectionIndex chan SelectionIndex
+	// The fields below are only ever accessed from the EventProcessor's goroutine
 
-	// wordGuess allows the message queue to process a ChatEvent as a word guess when the model.GameStatus is started
-	// and the model.TurnStatus is drawing
-	wordGuess chan Guess
+	// turn is the state of the current turn in the game loop's state machine
+	turn turn
+
+	// timer advances the current phase of the turn, and is nil while no game is in progress
+	timer clock.Timer
+
+	// tempLineSeq is the sequence number of the last event sent before the drawing's last temporary line segment,
+	// which users who resume their connection from this sequence number or later may have missed
+	tempLineSeq uint64
+
+	// skippedTurns counts the number of consecutive turns skipped due to the drawer being unavailable
+	skippedTurns int
+
+	// leaderSuccession is started when the room leader disconnects, and passes leadership on to another user once the
+	// grace period has elapsed without the room leader reconnecting
+	leaderSuccession clock.Timer
+
+	// leaderSuccessionID is the ID of the disconnected room leader that leaderSuccession is waiting on
+	leaderSuccessionID string
 }
 
-func NewGameStateProcessor(roomID string) GameState {
+func NewGameStateProcessor(roomID string, opts Options) GameState {
 	s := settings.DefaultSettings()
 
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+
 	return &GameStateProcessor{
-		roomID:             roomID,
-		status:             status.NewGameStatus(s),
-		players:            players.NewPlayerContainer(s.MaxPlayers),
-		drawingHistory:     drawing.NewDrawingHistory(),
-		chatHistory:        chat.NewChatHistory(),
-		cleanedUpChan:      make(chan bool),
-		messageQueue:       make(chan []byte),
-		wordSelectionIndex: make(chan SelectionIndex),
-		wordGuess:          make(chan Guess),
+		roomID:         roomID,
+		clock:          clk,
+		rng:            rng,
+		status:         status.NewGameStatus(s, rng),
+		access:         access.NewGate(opts.Passcode),
+		players:        players.NewPlayerContainer(s.MaxPlayers),
+		drawingHistory: drawing.NewDrawingHistory(),
+		chatHistory:    chat.NewChatHistory(),
+		replay:         replay.NewRingBuffer(replay.DefaultSize),
+		cleanedUpChan:  make(chan bool),
+		messageQueue:   make(chan user.Message),
+		joins:          make(chan joinRequest),
+		leaves:         make(chan *user.User),
+		requests:       make(chan eventRequest),
+		done:           make(chan struct{}),
 	}
 }
 
-// EventLoop represents the single-threaded game logic, which handles and processes incoming WebSocket messages from
-// players, as well as handles cleaning up the room when all users have left the room.
+// EventProcessor represents the single-threaded game logic. It is the only goroutine which mutates the room's state,
+// handling incoming WebSocket messages from players, users connecting to and disconnecting from the room, the timers of
+// the game loop, and cleaning up the room when all users have left the room.
 func (g *GameStateProcessor) EventProcessor(cleanupChan chan bool) {
+	defer close(g.done)
+
 	for {
 		select {
 		case msg := <-g.messageQueue:
-			var event events.GameEvent
-			err := json.Unmarshal(msg, &event)
-			if err != nil {
-				log.Error().
-					Bytes("msg", msg).
-					Err(err).
-					Msg("Failed to parse incoming user event")
-			}
-
-			switch event.Type {
-			case events.EventTypeUserJoinLeave:
-			case events.EventTypeRehydrate:
-			case events.EventTypeStartGame:
-			case events.EventTypeTurnWordSelection:
-			case events.EventTypeTurnDrawing:
-			case events.EventTypeTurnEnd:
-			case events.EventTypeAwardPoints:
-			case events.EventTypeGameOver:
-			case events.EventTypeNewGameReset:
-				g.warnServerSourcedEvent(event.Type)
-
-			case events.EventTypeChat:
-				var chatEvent events.ChatEvent
-				err := json.Unmarshal(event.Data, &chatEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onChatEvent(chatEvent)
-
-			case events.EventTypeDraw:
-				var drawEvent events.DrawEvent
-				err := json.Unmarshal(event.Data, &drawEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onDrawEvent(drawEvent)
-
-			case events.EventTypeReady:
-				var readyEvent events.ReadyEvent
-				err := json.Unmarshal(event.Data, &readyEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onReadyEvent(readyEvent)
-
-			case events.EventTypeStartGameIssued:
-				var startGameIssuedEvent events.StartGameIssuedEvent
-				err := json.Unmarshal(event.Data, &startGameIssuedEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onStartGameIssuedEvent(startGameIssuedEvent)
-
-			case events.EventTypeTurnWordSelected:
-				var turnWordSelectedEvent events.TurnWordSelectedEvent
-				err := json.Unmarshal(event.Data, &turnWordSelectedEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onTurnWordSelectedEvent(turnWordSelectedEvent)
-
-			case events.EventTypeNewGameIssued:
-				var newGameIssuedEvent events.NewGameIssuedEvent
-				err := json.Unmarshal(event.Data, &newGameIssuedEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onNewGameIssued(newGameIssuedEvent)
-
-			case events.EventTypeDrawTemp:
-				var drawTempEvent events.DrawTempEvent
-				err := json.Unmarshal(event.Data, &drawTempEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onDrawTempEvent(drawTempEvent)
-
-			case events.EventTypeDrawTempStop:
-				var drawTempStopEvent events.DrawTempStopEvent
-				err := json.Unmarshal(event.Data, &drawTempStopEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onDrawTempStopEvent(drawTempStopEvent)
-
-			case events.EventTypeDrawSelectColour:
-				var drawSelectColourEvent events.DrawSelectColourEvent
-				err := json.Unmarshal(event.Data, &drawSelectColourEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onDrawSelectColour(drawSelectColourEvent)
-
-			case events.EventTypeDrawSelectThickness:
-				var drawSelectThicknessEvent events.DrawSelectThicknessEvent
-				err := json.Unmarshal(event.Data, &drawSelectThicknessEvent)
-				if err != nil {
-					log.Error().Err(err).Msg("Could not unmarshal " + event.Type.String() + " from user")
-				}
-				g.onDrawSelectThickness(drawSelectThicknessEvent)
-
-			default:
-				log.Error().Msg("Unknown event type unmarshalled from incoming user event")
-			}
+			g.handleMessage(msg)
+
+		case req := <-g.joins:
+			req.result <- g.handleJoin(req)
+
+		case u := <-g.leaves:
+			g.handleLeave(u)
+
+		case req := <-g.requests:
+			req.result <- g.handleEventRequest(req)
+
+		case <-g.timerChan():
+			g.onTimer()
+
+		case <-g.leaderSuccessionChan():
+			g.succeedRoomLeader()
+
 		case <-cleanupChan:
 			g.cleanup()
 			return
@@ -195,25 +213,88 @@ func (g *GameStateProcessor) EventProcessor(cleanupChan chan bool) {
 	}
 }
 
+// handleMessage parses an incoming user event and dispatches it to its listener, acknowledging the event on the
+// connection it was read from
+func (g *GameStateProcessor) handleMessage(msg user.Message) {
+	event := msg.Event
+
+	// The sender is always the user bound to the connection the message was read from
+	player, ok := g.players.GetPlayer(msg.UserID)
+	if !ok {
+		log.Error().
+			Str("uid", msg.UserID).
+			Msg("Dropping incoming event from a user who is not in the room")
+		g.reply(msg, events.Nack(event.ID, event.Type, events.Reject(events.ErrorNotInRoom, "Not in the room")))
+		return
+	}
+	sender := player.ToUserModel()
+
+	var actionErr *events.ActionError
+	if err := g.dispatch(sender, event, msg.Codec); err != nil {
+		if !errors.As(err, &actionErr) {
+			actionErr = events.Reject(events.ErrorMalformedEvent, err.Error())
+		}
+		log.Error().
+			Str("uid", sender.ID).
+			Str("event", event.Type.String()).
+			Str("code", string(actionErr.Code)).
+			Msg(actionErr.Message)
+		g.reply(msg, events.Nack(event.ID, event.Type, actionErr))
+		return
+	}
+	if event.ID != "" {
+		g.reply(msg, events.Ack(event.ID, event.Type))
+	}
+}
+
+// reply sends an unsequenced event to the connection that a message was read from
+func (g *GameStateProcessor) reply(msg user.Message, event events.SerializableEvent) {
+	if msg.From != nil {
+		msg.From.SendEvent(event, 0)
+	}
+}
+
+// malformedEvent rejects an event whose payload could not be parsed
+func malformedEvent(eventType events.GameEventType, err error) *events.ActionError {
+	return events.Reject(events.ErrorMalformedEvent, "Could not unmarshal "+eventType.String()+": "+err.Error())
+}
+
+// dispatch decodes and validates an event's payload with the codec it was encoded with, and passes it to the event's
+// registered handler, returning an error if the event was rejected
+func (g *GameStateProcessor) dispatch(sender model.User, event events.GameEvent, c codec.Codec) error {
+	handler, ok := clientEvents[event.Type]
+	if !ok {
+		if source, ok := event.Type.Source(); ok && source == events.ServerSourced {
+			return g.rejectServerSourcedEvent(event.Type)
+		}
+		return events.Reject(events.ErrorUnknownEvent, "Unknown event type "+event.Type.Name())
+	}
+
+	payload, err := handler.decode(event, c)
+	if err != nil {
+		return err
+	}
+	return handler.handle(g, sender, payload)
+}
+
 func (g *GameStateProcessor) cleanup() {
 	log.Info().Str("roomID", g.roomID).Msg("Cleaning up game state processor")
 	defer log.Info().Str("roomID", g.roomID).Msg("Done cleaning up game state processor!")
 
+	// Stop the game loop's timers
+	g.stopGame()
+	g.cancelLeaderSuccession()
+
 	// Cleanup game state processor
 	g.chatHistory.Clear()
-	g.drawingHistory.Clear()
+	g.drawingHistory.Reset()
+	g.replay.Clear()
 	g.status.Reset()
 	g.players.Cleanup()
 	g.chatHistory = nil
 	g.drawingHistory = nil
 	g.status = nil
 	g.players = nil
-	close(g.messageQueue)
-	close(g.wordSelectionIndex)
-	close(g.wordGuess)
-
-	// TODO: close channels if necessary
-
 	// after cleanup, signal to the room that the game state is done cleaning up
 	g.cleanedUpChan <- true
 }
@@ -226,12 +307,64 @@ func (g *GameStateProcessor) Status() model.GameStatus {
 	return g.status.Status()
 }
 
-func (g *GameStateProcessor) StartGame() bool {
+func (g *GameStateProcessor) Access() access.Control {
+	return g.access
+}
+
+func (g *GameStateProcessor) Listing() model.RoomListing {
+	playerCount := g.players.PlayerCount()
+	maxPlayers := g.players.MaxPlayers()
+	gameStatus := g.status.Status()
+
+	return model.RoomListing{
+		RoomID:      g.roomID,
+		PlayerCount: playerCount,
+		MaxPlayers:  maxPlayers,
+		Status:      gameStatus,
+		Round:       g.status.CurrentRound(),
+		LeaderName:  g.players.RoomLeaderName(),
+		Joinable:    gameStatus == model.GameWaitingReadyUp && playerCount < maxPlayers,
+
+		RequiresPasscode: g.access.RequiresPasscode(),
+	}
+}
+
+func (g *GameStateProcessor) RoomSummary() model.RoomSummary {
+	playersSummary := g.players.Summary()
+
+	playerStates := make([]model.PlayerState, 0, len(playersSummary.PlayerStates))
+	spectatorStates := make([]model.PlayerState, 0)
+	for _, playerState := range playersSummary.PlayerStates {
+		if playerState.IsSpectator {
+			spectatorStates = append(spectatorStates, playerState)
+		} else {
+			playerStates = append(playerStates, playerState)
+		}
+	}
+
+	gameStatus := g.status.Status()
+
+	return model.RoomSummary{
+		RoomID:     g.roomID,
+		Status:     gameStatus,
+		TurnStatus: g.status.TurnStatus(),
+		Round:      g.status.CurrentRound(),
+		Settings:   g.status.Settings(),
+		Players:    playerStates,
+		Spectators: spectatorStates,
+		MaxPlayers: playersSummary.MaxPlayers,
+		Joinable:   gameStatus == model.GameWaitingReadyUp && len(playerStates) < playersSummary.MaxPlayers,
+
+		RequiresPasscode: g.access.RequiresPasscode(),
+	}
+}
+
+// startGame starts the game if all players are ready, returning an error otherwise
+func (g *GameStateProcessor) startGame() error {
 	// Check all players are ready
 	playerOrderIDs, ok := g.players.AllPlayersReady()
 	if !ok {
-		log.Error().Msg("Attempted to start the game but not all players are ready!")
-		return false
+		return events.Reject(events.ErrorPlayersNotReady, "Not all players are ready")
 	}
 
 	// Sanity check that the length of ready users is <= room's max capacity
@@ -241,11 +374,11 @@ func (g *GameStateProcessor) StartGame() bool {
 			Int("numPlayersReady", numPlayersReady).
 			Int("maxPlayers", g.players.MaxPlayers()).
 			Msg("Number of ready players somehow exceeds the room's max capacity!")
-		return false
+		return events.Reject(events.ErrorPlayersNotReady, "Number of ready players exceeds the room's capacity")
 	}
 
 	// Randomize player turn order
-	rand.Shuffle(numPlayersReady, func(i, j int) {
+	g.rng.Shuffle(numPlayersReady, func(i, j int) {
 		playerOrderIDs[i], playerOrderIDs[j] = playerOrderIDs[j], playerOrderIDs[i]
 	})
 
@@ -258,19 +391,49 @@ func (g *GameStateProcessor) StartGame() bool {
 	// Set status to game started
 	g.status.SetStatus(model.GameStarted)
 
-	// Progress game state logic with timer
-	go g.gameLoop()
+	// Reset ready for all players since the game has already started
+	g.players.UnreadyAllPlayers()
 
-	return true
+	// Progress game state logic with the game loop's state machine
+	g.skippedTurns = 0
+	g.beginTurn()
+
+	return nil
+}
+
+// HandleUserConnection hands a user's new connection over to the EventProcessor, returning once the user has joined
+// the room.
+func (g *GameStateProcessor) HandleUserConnection(ctx context.Context, user *user.User, connErrChan chan error) error {
+	req := joinRequest{
+		ctx:         ctx,
+		user:        user,
+		connErrChan: connErrChan,
+		result:      make(chan error, 1),
+	}
+
+	select {
+	case g.joins <- req:
+	case <-g.done:
+		return ErrRoomClosed
+	}
+	return <-req.result
 }
 
-func (g *GameStateProcessor) HandleUserConnection(ctx context.Context, user *user.User, connErrChan chan error) {
+func (g *GameStateProcessor) handleJoin(req joinRequest) error {
+	_, wasInRoom := g.players.GetPlayer(req.user.ID)
+
 	// Save user connection
-	player := g.players.SaveConnection(user, g.status.Status() == model.GameStarted)
+	player, ok := g.players.SaveConnection(req.user, g.status.Status() == model.GameStarted)
+	if !ok {
+		return ErrBanned
+	}
+
+	// Load the words the user has seen in other rooms, before words are next offered to the drawer
+	words.Histories().Preload(player.ID())
 
 	// Concurrently handle the user's WebSocket connection
-	go user.ReaderLoop(ctx, g.messageQueue, connErrChan)
-	go user.WriterLoop(ctx, connErrChan)
+	go req.user.ReaderLoop(req.ctx, g.messageQueue, req.connErrChan)
+	go req.user.WriterLoop(req.ctx, req.connErrChan)
 
 	userModel := model.User{
 		ID:   player.ID(),
@@ -287,46 +450,210 @@ func (g *GameStateProcessor) HandleUserConnection(ctx context.Context, user *use
 		currentTurnUserPtr = &currentTurnUser
 	}
 
-	// Send rehydration event to user who just joined
-	g.emit(
-		events.RehydrateForUser(
-			userModel,
-			currentTurnUserPtr,
-			g.chatHistory.GetAll(),
-			g.players.Summary(),
-			g.status.Summary(selfUserIsCurrentTurn),
-			g.drawingHistory.GetAll(),
-		),
-		userModel.ID,
-	)
+	// Send the events missed by a reconnecting user, or otherwise send a rehydration event to the user who just joined.
+	// The rehydration event is numbered with the last sequence number, as it already includes every event before it.
+	if !(wasInRoom && g.resume(req)) {
+		g.players.SendEventToUser(
+			events.RehydrateForUser(
+				userModel,
+				currentTurnUserPtr,
+				g.chatHistory.GetAll(),
+				g.players.Summary(),
+				g.status.Summary(selfUserIsCurrentTurn, g.now()),
+				g.drawingHistory.GetAll(),
+			),
+			g.replay.LastSeq(),
+			userModel.ID,
+		)
+	}
+
+	// Cancel the room leader succession if the room leader has reconnected
+	roomLeaderID := g.players.RoomLeaderID()
+	if roomLeaderID == userModel.ID {
+		g.cancelLeaderSuccession()
+	}
 
 	// Broadcast user joined
-	g.broadcast(events.UserJoin(player.ToModel(g.players.RoomLeaderID())))
+	g.broadcast(events.UserJoin(player.ToModel(roomLeaderID)))
 	g.broadcastChat(events.ChatUserJoined(userModel, player.IsSpectator()))
+
+	// Resume the drawing phase if the drawer has reconnected
+	if g.turn.phase == phaseDrawing && g.turn.drawer.ID == userModel.ID {
+		g.resumeTurnDrawing()
+	}
+	return nil
 }
 
-func (g *GameStateProcessor) RemoveUserConnection(userID string) {
-	// Remove user connection
-	player := g.players.RemoveConnection(userID)
+// resume replays the events that a reconnecting user has missed since the last event they received, returning false if
+// the user did not present the last event they received, or if too many events were missed
+func (g *GameStateProcessor) resume(req joinRequest) bool {
+	lastSeq, ok := ctxs.LastSeq(req.ctx)
+	if !ok {
+		return false
+	}
+
+	missed, ok := g.replay.Since(lastSeq, req.user.ID)
+	if !ok || len(missed) > req.user.QueueSize() {
+		log.Debug().
+			Str("uid", req.user.ID).
+			Uint64("lastSeq", lastSeq).
+			Msg("Unable to resume from the last event received, falling back to rehydration")
+		return false
+	}
+
+	log.Debug().
+		Str("uid", req.user.ID).
+		Uint64("lastSeq", lastSeq).
+		Int("missed", len(missed)).
+		Msg("Resuming from the last event received")
+	for _, entry := range missed {
+		g.players.SendEventToUser(entry.Event, entry.Seq, req.user.ID)
+	}
+	g.resumeUnsequenced(req.user.ID, lastSeq)
+	return true
+}
+
+// resumeUnsequenced sends the room's state which superseded the unsequenced events that a user may have missed after
+// the given sequence number
+func (g *GameStateProcessor) resumeUnsequenced(userID string, lastSeq uint64) {
+	if g.tempLineSeq != 0 && g.tempLineSeq >= lastSeq {
+		snapshot := events.DrawSnapshotEvent{Lines: g.drawingHistory.GetAll()}
+		if line, ok := g.drawingHistory.TempLine(); ok {
+			snapshot.Temp = &line
+		}
+		g.emitUnsequenced(snapshot, userID)
+	}
+	if g.status.Status() == model.GameStarted && g.turn.phase == phaseDrawing {
+		g.emitUnsequenced(g.turnDrawingUpdate(), userID)
+	}
+}
+
+// HandleEvent hands an event sent by a user outside of their WebSocket connection, with a JSON payload, over to the
+// EventProcessor, returning the *events.ActionError which rejected the event, or nil once the event has been handled.
+func (g *GameStateProcessor) HandleEvent(userID string, event events.GameEvent) error {
+	req := eventRequest{
+		userID: userID,
+		event:  event,
+		result: make(chan error, 1),
+	}
+
+	select {
+	case g.requests <- req:
+	case <-g.done:
+		return ErrRoomClosed
+	}
+	return <-req.result
+}
+
+func (g *GameStateProcessor) handleEventRequest(req eventRequest) error {
+	player, ok := g.players.GetPlayer(req.userID)
+	if !ok {
+		return events.Reject(events.ErrorNotInRoom, "Not in the room")
+	}
+	return g.dispatch(player.ToUserModel(), req.event, codec.JSON)
+}
+
+// RemoveUserConnection hands a user's closed connection over to the EventProcessor
+func (g *GameStateProcessor) RemoveUserConnection(user *user.User) {
+	select {
+	case g.leaves <- user:
+	case <-g.done:
+	}
+}
+
+func (g *GameStateProcessor) handleLeave(u *user.User) {
+	// Remove user connection, unless the user has since reconnected over a newer connection
+	player := g.players.RemoveConnection(u)
+	if player == nil {
+		return
+	}
 
 	// Broadcast user left
-	if player != nil {
-		userModel := player.ToUserModel()
-		g.broadcast(events.UserLeave(player.ToModel(g.players.RoomLeaderID())))
-		g.broadcastChat(events.ChatUserLeft(userModel))
+	userModel := player.ToUserModel()
+	roomLeaderID := g.players.RoomLeaderID()
+	g.broadcast(events.UserLeave(player.ToModel(roomLeaderID)))
+	g.broadcastChat(events.ChatUserLeft(userModel))
+
+	if userModel.ID == roomLeaderID {
+		g.startLeaderSuccession(userModel.ID)
+	}
+
+	// Pause the drawing phase while the drawer is disconnected
+	if g.turn.phase == phaseDrawing && g.turn.drawer.ID == userModel.ID {
+		g.pauseTurnDrawing()
+	}
+}
+
+// startLeaderSuccession waits for the room leader to reconnect within the room's grace period, before passing
+// leadership on to another user.
+func (g *GameStateProcessor) startLeaderSuccession(roomLeaderID string) {
+	gracePeriod := time.Duration(g.status.Settings().RoomLeaderGracePeriodSeconds) * time.Second
+
+	g.cancelLeaderSuccession()
+	g.leaderSuccession = g.clock.NewTimer(gracePeriod)
+	g.leaderSuccessionID = roomLeaderID
+}
+
+func (g *GameStateProcessor) cancelLeaderSuccession() {
+	if g.leaderSuccession != nil {
+		g.leaderSuccession.Stop()
+		g.leaderSuccession = nil
+	}
+	g.leaderSuccessionID = ""
+}
+
+// leaderSuccessionChan returns the succession timer's channel, or nil if no succession is pending so that the select
+// loop never fires it
+func (g *GameStateProcessor) leaderSuccessionChan() <-chan time.Time {
+	if g.leaderSuccession == nil {
+		return nil
+	}
+	return g.leaderSuccession.C()
+}
+
+// succeedRoomLeader passes leadership from a disconnected room leader to the longest-connected user. If nobody else is
+// connected, the room is left without a leader until the next user connects.
+func (g *GameStateProcessor) succeedRoomLeader() {
+	roomLeaderID := g.leaderSuccessionID
+	g.leaderSuccession = nil
+	g.leaderSuccessionID = ""
+
+	if g.players.RoomLeaderID() != roomLeaderID {
+		return
+	}
+	if roomLeader, ok := g.players.GetPlayer(roomLeaderID); ok && roomLeader.IsConnected() {
+		return
 	}
+
+	successor, ok := g.players.NextRoomLeader()
+	if !ok {
+		log.Debug().Str("roomID", g.roomID).Msg("No users left to succeed the room leader")
+		g.players.SetRoomLeader("")
+		return
+	}
+	g.changeRoomLeader(successor.ToUserModel())
+}
+
+// changeRoomLeader sets the new room leader and notifies all players
+func (g *GameStateProcessor) changeRoomLeader(newLeader model.User) bool {
+	if !g.players.SetRoomLeader(newLeader.ID) {
+		return false
+	}
+
+	log.Info().Str("roomID", g.roomID).Str("uid", newLeader.ID).Msg("Room leader changed")
+	g.broadcast(events.RoomLeaderEvent{User: newLeader})
+	g.broadcastChat(events.ChatUserRoomLeader(newLeader))
+	return true
 }
 
-func (g *GameStateProcessor) awardPoints(
-	guesser players.PlayerState, guesserPoints int,
-	drawer players.PlayerState, drawerPoints int,
-) {
-	guesser.AwardPoints(guesserPoints)
-	drawer.AwardPoints(drawerPoints)
+func (g *GameStateProcessor) awardPoints(guesser players.PlayerState, drawer players.PlayerState, award guess.Award) {
+	g.players.AwardPoints(guesser.ID(), award.GuesserPoints)
+	g.players.AwardPoints(drawer.ID(), award.DrawerPoints)
 	g.broadcast(events.AwardPointsEvent{
 		Guesser:       guesser.ToUserModel(),
 		Drawer:        drawer.ToUserModel(),
-		GuesserPoints: guesserPoints,
-		DrawerPoints:  drawerPoints,
+		GuesserPoints: award.GuesserPoints,
+		DrawerPoints:  award.DrawerPoints,
+		Breakdown:     award.Breakdown,
 	})
 }
@@ -0,0 +1,358 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+	"github.com/kvnxiao/pictorio/ctxs"
+	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/game/settings"
+	"github.com/kvnxiao/pictorio/game/user"
+	"github.com/kvnxiao/pictorio/model"
+	"github.com/segmentio/ksuid"
+	"nhooyr.io/websocket"
+)
+
+// eventTimeout is how long a test waits for an event, in real time
+const eventTimeout = 5 * time.Second
+
+// testRoom runs a GameStateProcessor on a manual clock, which users connect to over WebSocket connections to a test
+// server
+type testRoom struct {
+	t         *testing.T
+	processor *GameStateProcessor
+	clock     *clock.Manual
+	server    *httptest.Server
+}
+
+func newTestRoom(t *testing.T) *testRoom {
+	clk := clock.NewManual(time.Unix(1600000000, 0))
+	processor := NewGameStateProcessor("room", Options{Clock: clk, Seed: 1}).(*GameStateProcessor)
+	cleanup := make(chan bool)
+	go processor.EventProcessor(cleanup)
+
+	room := &testRoom{t: t, processor: processor, clock: clk}
+	room.server = httptest.NewServer(http.HandlerFunc(room.connect))
+	t.Cleanup(func() {
+		room.server.Close()
+		cleanup <- true
+		<-processor.Cleanup()
+		<-processor.done
+	})
+	return room
+}
+
+// connect joins the user named in the request's query parameters to the room, the same way as game.Room does
+func (r *testRoom) connect(w http.ResponseWriter, req *http.Request) {
+	userID, err := ksuid.Parse(req.URL.Query().Get("uid"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := websocket.Accept(w, req, nil)
+	if err != nil {
+		return
+	}
+
+	ctx := context.WithValue(req.Context(), ctxs.KeyUserID, userID)
+	if lastSeq, err := strconv.ParseUint(req.URL.Query().Get("lastSeq"), 10, 64); err == nil {
+		ctx = context.WithValue(ctx, ctxs.KeyLastSeq, lastSeq)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	u := user.NewUser(conn, model.User{ID: userID.String(), Name: req.URL.Query().Get("name")},
+		events.Handshake{Version: events.ProtocolVersion})
+	connErrChan := make(chan error, 2)
+	if err := r.processor.HandleUserConnection(ctx, u, connErrChan); err != nil {
+		_ = conn.Close(websocket.StatusPolicyViolation, err.Error())
+		return
+	}
+	<-connErrChan
+	r.processor.RemoveUserConnection(u)
+}
+
+// testClient is a user connected to a testRoom
+type testClient struct {
+	t      *testing.T
+	user   model.User
+	conn   *websocket.Conn
+	events chan events.GameEvent
+	// lastSeq is the sequence number of the last event expected by the test
+	lastSeq uint64
+}
+
+func (r *testRoom) join(name string) *testClient {
+	r.t.Helper()
+
+	c := r.dial(model.User{ID: ksuid.New().String(), Name: name}, "")
+	c.expect(events.EventTypeRehydrate, nil)
+	return c
+}
+
+// resume reconnects a closed client's user to the room, resuming from the last event the client has expected
+func (r *testRoom) resume(c *testClient) *testClient {
+	r.t.Helper()
+
+	return r.dial(c.user, "&lastSeq="+strconv.FormatUint(c.lastSeq, 10))
+}
+
+func (r *testRoom) dial(u model.User, query string) *testClient {
+	r.t.Helper()
+
+	name := u.Name
+	url := strings.Replace(r.server.URL, "http", "ws", 1) + "?uid=" + u.ID + "&name=" + name + query
+	conn, _, err := websocket.Dial(context.Background(), url, nil)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	conn.SetReadLimit(1 << 20)
+
+	c := &testClient{t: r.t, user: u, conn: conn, events: make(chan events.GameEvent, 256)}
+	go func() {
+		defer close(c.events)
+		for {
+			_, msg, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			var event events.GameEvent
+			if err := json.Unmarshal(msg, &event); err != nil {
+				r.t.Errorf("%s received a malformed event %s: %v", name, msg, err)
+				return
+			}
+			c.events <- event
+		}
+	}()
+	r.t.Cleanup(func() {
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+	})
+	return c
+}
+
+// close closes the client's connection, and discards the events it has not expected
+func (c *testClient) close() {
+	_ = c.conn.Close(websocket.StatusNormalClosure, "")
+	for range c.events {
+	}
+}
+
+func (c *testClient) send(eventType events.GameEventType, data string) {
+	c.t.Helper()
+	c.sendWithID(eventType, "", data)
+}
+
+// sendWithID sends an event with an ID, so that it is acknowledged once it has been handled
+func (c *testClient) sendWithID(eventType events.GameEventType, id string, data string) {
+	c.t.Helper()
+
+	msg := fmt.Sprintf(`{"type":%q,"id":%q,"data":%s}`, eventType.Name(), id, data)
+	if err := c.conn.Write(context.Background(), websocket.MessageText, []byte(msg)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// expect skips events until an event of the given type is received, and decodes it into v if v is not nil. A
+// negative acknowledgement of any event fails the test.
+func (c *testClient) expect(eventType events.GameEventType, v interface{}) {
+	c.t.Helper()
+
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case event, ok := <-c.events:
+			if !ok {
+				c.t.Fatalf("%s was disconnected while waiting for %s", c.user.Name, eventType.Name())
+			}
+			if event.Type == events.EventTypeAck {
+				var ack events.AckEvent
+				_ = json.Unmarshal(event.Data, &ack)
+				if !ack.OK {
+					c.t.Fatalf("%s's %s was rejected: %s", c.user.Name, ack.Event.Name(), ack.Message)
+				}
+			}
+			if event.Seq != 0 {
+				c.lastSeq = event.Seq
+			}
+			if event.Type != eventType {
+				continue
+			}
+			if v != nil {
+				if err := json.Unmarshal(event.Data, v); err != nil {
+					c.t.Fatalf("could not unmarshal %s: %v", eventType.Name(), err)
+				}
+			}
+			return
+		case <-timeout:
+			c.t.Fatalf("%s did not receive %s", c.user.Name, eventType.Name())
+		}
+	}
+}
+
+func seconds(s int) time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+func TestPlayTurns(t *testing.T) {
+	room := newTestRoom(t)
+	alice := room.join("alice")
+	bob := room.join("bob")
+	clients := map[string]*testClient{alice.user.ID: alice, bob.user.ID: bob}
+	defaults := settings.DefaultSettings()
+
+	alice.send(events.EventTypeCustomWords, `{"words":["apple","banana","cherry"],"mode":"only"}`)
+	alice.expect(events.EventTypeCustomWords, nil)
+	alice.send(events.EventTypeReady, `{"ready":true}`)
+	bob.send(events.EventTypeReady, `{"ready":true}`)
+	bob.expect(events.EventTypeReady, nil)
+	bob.expect(events.EventTypeReady, nil)
+	alice.send(events.EventTypeStartGameIssued, `{}`)
+
+	// The first turn ends once the guesser guesses the word selected by the drawer
+	var next events.TurnNextPlayerEvent
+	alice.expect(events.EventTypeTurnNextPlayer, &next)
+	bob.expect(events.EventTypeTurnNextPlayer, nil)
+	drawer := clients[next.Nonce.NextTurnUser.ID]
+	guesser := alice
+	if drawer == alice {
+		guesser = bob
+	}
+
+	room.clock.Advance(seconds(defaults.MaxTurnNextPlayerTimeSeconds))
+	var selection events.TurnWordSelectionEvent
+	drawer.expect(events.EventTypeTurnWordSelection, &selection)
+	if len(selection.Nonce.Words) != defaults.MaxSelectableWords {
+		t.Fatalf("drawer was offered %v", selection.Nonce.Words)
+	}
+
+	drawer.send(events.EventTypeTurnWordSelected, `{"index":1}`)
+	var drawing events.TurnDrawingEvent
+	drawer.expect(events.EventTypeTurnDrawing, &drawing)
+	if drawing.Nonce.Word == nil || *drawing.Nonce.Word != selection.Nonce.Words[1] {
+		t.Fatalf("drawer is drawing %v, expected %s", drawing.Nonce.Word, selection.Nonce.Words[1])
+	}
+	word := *drawing.Nonce.Word
+	guesser.expect(events.EventTypeTurnDrawing, nil)
+
+	// The room's summary is read by HTTP handlers while points are awarded
+	summaries := make(chan model.RoomSummary)
+	go func() {
+		defer close(summaries)
+		for i := 0; i < 100; i++ {
+			summaries <- room.processor.RoomSummary()
+		}
+	}()
+
+	room.clock.Advance(seconds(10))
+	guesser.send(events.EventTypeChat, fmt.Sprintf(`{"message":%q}`, strings.ToUpper(word)))
+	var award events.AwardPointsEvent
+	drawer.expect(events.EventTypeAwardPoints, &award)
+	if award.Guesser.ID != guesser.user.ID || award.GuesserPoints != 3 || award.DrawerPoints != 2 {
+		t.Errorf("awarded %+v, expected 3 points to the guesser and 2 to the drawer", award)
+	}
+	for range summaries {
+	}
+
+	var end events.TurnEndEvent
+	guesser.expect(events.EventTypeTurnEnd, &end)
+	if end.Nonce.Answer != word || end.Nonce.Reason != events.TurnEndAllGuessed {
+		t.Errorf("turn ended with %+v, expected every player to have guessed %s", end.Nonce, word)
+	}
+	drawer.expect(events.EventTypeTurnEnd, nil)
+
+	// The second turn passes to the other player, and ends once the drawing time runs out, after the word is selected
+	// for the drawer
+	room.clock.Advance(seconds(defaults.MaxTurnEndTimeSeconds))
+	guesser.expect(events.EventTypeTurnNextPlayer, &next)
+	if next.Nonce.NextTurnUser.ID != guesser.user.ID {
+		t.Fatalf("second turn was given to %s, expected %s", next.Nonce.NextTurnUser.Name, guesser.user.Name)
+	}
+	drawer, guesser = guesser, drawer
+
+	room.clock.Advance(seconds(defaults.MaxTurnNextPlayerTimeSeconds))
+	drawer.expect(events.EventTypeTurnWordSelection, nil)
+	room.clock.Advance(seconds(defaults.MaxTurnSelectionTimeSeconds))
+	drawer.expect(events.EventTypeTurnDrawing, &drawing)
+	word = *drawing.Nonce.Word
+
+	room.clock.Advance(seconds(defaults.MaxTurnDrawingTimeSeconds))
+	guesser.expect(events.EventTypeTurnEnd, &end)
+	if end.Nonce.Answer != word || end.Nonce.Reason != events.TurnEndTimeout {
+		t.Errorf("turn ended with %+v, expected the drawing time of %s to run out", end.Nonce, word)
+	}
+}
+
+func TestResumeSendsDrawingSnapshot(t *testing.T) {
+	room := newTestRoom(t)
+	alice := room.join("alice")
+	bob := room.join("bob")
+	clients := map[string]*testClient{alice.user.ID: alice, bob.user.ID: bob}
+	defaults := settings.DefaultSettings()
+
+	alice.send(events.EventTypeCustomWords, `{"words":["apple","banana","cherry"],"mode":"only"}`)
+	alice.expect(events.EventTypeCustomWords, nil)
+	alice.send(events.EventTypeReady, `{"ready":true}`)
+	bob.send(events.EventTypeReady, `{"ready":true}`)
+	bob.expect(events.EventTypeReady, nil)
+	bob.expect(events.EventTypeReady, nil)
+	alice.send(events.EventTypeStartGameIssued, `{}`)
+
+	var next events.TurnNextPlayerEvent
+	alice.expect(events.EventTypeTurnNextPlayer, &next)
+	bob.expect(events.EventTypeTurnNextPlayer, nil)
+	drawer := clients[next.Nonce.NextTurnUser.ID]
+	guesser := alice
+	if drawer == alice {
+		guesser = bob
+	}
+
+	room.clock.Advance(seconds(defaults.MaxTurnNextPlayerTimeSeconds))
+	drawer.expect(events.EventTypeTurnWordSelection, nil)
+	drawer.send(events.EventTypeTurnWordSelected, `{"index":0}`)
+	drawer.expect(events.EventTypeTurnDrawing, nil)
+	guesser.expect(events.EventTypeTurnDrawing, nil)
+
+	// The guesser receives the first segment of a line, and misses the rest of the line and the start of the next line
+	drawer.send(events.EventTypeDrawTemp, `{"line":{"points":[{"x":0.1,"y":0.1}],"colourIdx":1,"thicknessIdx":1}}`)
+	guesser.expect(events.EventTypeDrawTemp, nil)
+	guesser.close()
+	drawer.send(events.EventTypeDrawTemp, `{"line":{"points":[{"x":0.2,"y":0.2}],"colourIdx":1,"thicknessIdx":1}}`)
+	drawer.send(events.EventTypeDrawTempStop, `{"line":{"points":[{"x":0.3,"y":0.3}],"colourIdx":1,"thicknessIdx":1}}`)
+	drawer.sendWithID(events.EventTypeDrawTemp, "next",
+		`{"line":{"points":[{"x":0.4,"y":0.4}],"colourIdx":2,"thicknessIdx":1}}`)
+	drawer.expect(events.EventTypeAck, nil)
+
+	// The room's listing is read by HTTP handlers while the guesser reconnects
+	listings := make(chan model.RoomListing)
+	go func() {
+		defer close(listings)
+		for i := 0; i < 100; i++ {
+			listings <- room.processor.Listing()
+		}
+	}()
+	guesser = room.resume(guesser)
+	for range listings {
+	}
+
+	var snapshot events.DrawSnapshotEvent
+	guesser.expect(events.EventTypeDrawSnapshot, &snapshot)
+	if len(snapshot.Lines) != 1 || len(snapshot.Lines[0].Points) != 3 {
+		t.Fatalf("snapshot has lines %+v, expected the line of 3 points", snapshot.Lines)
+	}
+	if snapshot.Temp == nil || len(snapshot.Temp.Points) != 1 || snapshot.Temp.ColourIdx != 2 {
+		t.Fatalf("snapshot has temporary line %+v, expected the line being drawn", snapshot.Temp)
+	}
+
+	var update events.TurnDrawingEvent
+	guesser.expect(events.EventTypeTurnDrawing, &update)
+	if update.Nonce != nil || update.TimeLeft != defaults.MaxTurnDrawingTimeSeconds {
+		t.Errorf("resumed with %+v, expected an update of the drawing phase's countdown", update)
+	}
+}
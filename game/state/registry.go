@@ -0,0 +1,104 @@
+package state
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/kvnxiao/pictorio/codec"
+	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// clientEvent decodes, validates and handles a client-sourced event type
+type clientEvent struct {
+	// payloadType is the event's payload struct, which is the third parameter of the handler
+	payloadType reflect.Type
+	// handler is a method expression of the form func(*GameStateProcessor, model.User, payloadType) error
+	handler reflect.Value
+}
+
+// clientEvents is the registry of every event type which clients may send
+var clientEvents = make(map[events.GameEventType]clientEvent)
+
+func init() {
+	registerClientEvent(events.EventTypeChat, (*GameStateProcessor).onChatEvent)
+	registerClientEvent(events.EventTypeDraw, (*GameStateProcessor).onDrawEvent)
+	registerClientEvent(events.EventTypeDrawTemp, (*GameStateProcessor).onDrawTempEvent)
+	registerClientEvent(events.EventTypeDrawTempStop, (*GameStateProcessor).onDrawTempStopEvent)
+	registerClientEvent(events.EventTypeDrawSelectColour, (*GameStateProcessor).onDrawSelectColour)
+	registerClientEvent(events.EventTypeDrawSelectThickness, (*GameStateProcessor).onDrawSelectThickness)
+	registerClientEvent(events.EventTypeReady, (*GameStateProcessor).onReadyEvent)
+	registerClientEvent(events.EventTypeStartGameIssued, (*GameStateProcessor).onStartGameIssuedEvent)
+	registerClientEvent(events.EventTypeTurnWordSelected, (*GameStateProcessor).onTurnWordSelectedEvent)
+	registerClientEvent(events.EventTypeNewGameIssued, (*GameStateProcessor).onNewGameIssued)
+	registerClientEvent(events.EventTypeUpdateSettings, (*GameStateProcessor).onUpdateSettingsEvent)
+	registerClientEvent(events.EventTypeCustomWords, (*GameStateProcessor).onCustomWordsEvent)
+	registerClientEvent(events.EventTypeRoomPasscode, (*GameStateProcessor).onRoomPasscodeEvent)
+	registerClientEvent(events.EventTypeKickPlayer, (*GameStateProcessor).onKickPlayerEvent)
+	registerClientEvent(events.EventTypeBanPlayer, (*GameStateProcessor).onBanPlayerEvent)
+	registerClientEvent(events.EventTypeRoomLeader, (*GameStateProcessor).onRoomLeaderEvent)
+	registerClientEvent(events.EventTypeClockSync, (*GameStateProcessor).onClockSyncEvent)
+}
+
+var (
+	processorType = reflect.TypeOf((*GameStateProcessor)(nil))
+	userType      = reflect.TypeOf(model.User{})
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// registerClientEvent registers the handler of a client-sourced event type. The handler's payload parameter must be
+// the event type's payload, and it panics otherwise so that a mismatch is caught on startup.
+func registerClientEvent(eventType events.GameEventType, handler interface{}) {
+	if _, ok := clientEvents[eventType]; ok {
+		panic("duplicate handler registered for " + eventType.String())
+	}
+	source, ok := eventType.Source()
+	if !ok || source == events.ServerSourced {
+		panic("cannot register a handler for server-sourced event " + eventType.String())
+	}
+
+	h := reflect.ValueOf(handler)
+	t := h.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 3 || t.In(0) != processorType || t.In(1) != userType ||
+		t.NumOut() != 1 || t.Out(0) != errorType {
+		panic("handler for " + eventType.String() + " has an invalid signature: " + t.String())
+	}
+	prototype, _ := eventType.Prototype()
+	if t.In(2) != reflect.TypeOf(prototype) {
+		panic("handler for " + eventType.String() + " does not take a " + reflect.TypeOf(prototype).String())
+	}
+
+	clientEvents[eventType] = clientEvent{
+		payloadType: t.In(2),
+		handler:     h,
+	}
+}
+
+// decode unmarshals and validates an event's payload with the given codec. A missing or null payload is decoded as the
+// zero value, for events which carry no data.
+func (c clientEvent) decode(event events.GameEvent, decoder codec.Codec) (reflect.Value, error) {
+	payload := reflect.New(c.payloadType)
+	if len(event.Data) > 0 {
+		if err := decoder.Unmarshal(event.Data, payload.Interface()); err != nil {
+			return reflect.Value{}, malformedEvent(event.Type, err)
+		}
+	}
+
+	if validator, ok := payload.Elem().Interface().(events.Validator); ok {
+		if err := validator.Validate(); err != nil {
+			var actionErr *events.ActionError
+			if !errors.As(err, &actionErr) {
+				actionErr = events.Reject(events.ErrorInvalidEvent, err.Error())
+			}
+			return reflect.Value{}, actionErr
+		}
+	}
+	return payload.Elem(), nil
+}
+
+// handle passes a decoded payload to the event's handler
+func (c clientEvent) handle(g *GameStateProcessor, sender model.User, payload reflect.Value) error {
+	out := c.handler.Call([]reflect.Value{reflect.ValueOf(g), reflect.ValueOf(sender), payload})
+	err, _ := out[0].Interface().(error)
+	return err
+}
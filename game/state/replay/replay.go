@@ -0,0 +1,120 @@
+package replay
+
+import (
+	"sync"
+
+	"github.com/kvnxiao/pictorio/events"
+)
+
+// DefaultSize is the number of events kept for replay in each room
+const DefaultSize = 128
+
+// Entry is an event sent to the room, numbered by its sequence
+type Entry struct {
+	Seq   uint64
+	Event events.SerializableEvent
+
+	// To is the only user the event was sent to, if non-empty
+	To string
+	// Except is the user excluded from receiving the event, if non-empty
+	Except string
+}
+
+// IsFor checks whether the event was sent to the given user
+func (e Entry) IsFor(userID string) bool {
+	if e.To != "" {
+		return e.To == userID
+	}
+	return e.Except != userID
+}
+
+// Buffer numbers the events sent to a room with a monotonic sequence, and keeps the most recent events so that
+// reconnecting users can receive the events they missed
+type Buffer interface {
+	// Append numbers an event with the next sequence number, and records it for replay
+	Append(entry Entry) uint64
+	// LastSeq returns the sequence number of the last event appended
+	LastSeq() uint64
+	// Since returns the events sent to a user after the given sequence number, or false if some of those events are no
+	// longer kept in the buffer
+	Since(seq uint64, userID string) ([]Entry, bool)
+	// Clear removes all recorded events, without resetting the sequence
+	Clear()
+}
+
+// RingBuffer is a Buffer which keeps a fixed number of the most recent events
+type RingBuffer struct {
+	mu      sync.RWMutex
+	entries []Entry
+	next    int
+	count   int
+	lastSeq uint64
+}
+
+func NewRingBuffer(size int) Buffer {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &RingBuffer{
+		entries: make([]Entry, size),
+	}
+}
+
+func (b *RingBuffer) Append(entry Entry) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastSeq++
+	entry.Seq = b.lastSeq
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.count < len(b.entries) {
+		b.count++
+	}
+	return entry.Seq
+}
+
+func (b *RingBuffer) LastSeq() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.lastSeq
+}
+
+func (b *RingBuffer) Since(seq uint64, userID string) ([]Entry, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// The sequence is ahead of the room, e.g. from before the server restarted
+	if seq > b.lastSeq {
+		return nil, false
+	}
+
+	// Some of the missed events have already been overwritten
+	missed := b.lastSeq - seq
+	if missed > uint64(b.count) {
+		return nil, false
+	}
+
+	var entries []Entry
+	start := (b.next - int(missed) + len(b.entries)) % len(b.entries)
+	for i := 0; i < int(missed); i++ {
+		entry := b.entries[(start+i)%len(b.entries)]
+		if entry.IsFor(userID) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, true
+}
+
+func (b *RingBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := range b.entries {
+		b.entries[i] = Entry{}
+	}
+	b.next = 0
+	b.count = 0
+}
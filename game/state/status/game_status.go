@@ -1,16 +1,22 @@
 package status
 
 import (
+	"math/rand"
 	"sync"
+	"time"
 
+	"github.com/kvnxiao/pictorio/events"
 	"github.com/kvnxiao/pictorio/game/settings"
 	"github.com/kvnxiao/pictorio/model"
 	"github.com/kvnxiao/pictorio/words"
 )
 
 type GameStatus interface {
-	Summary(selfUserIsCurrentTurn bool) model.GameStateSummary
+	Summary(selfUserIsCurrentTurn bool, now time.Time) model.GameStateSummary
 	Settings() settings.GameSettings
+	SetSettings(gameSettings settings.GameSettings)
+	CustomWords() words.CustomList
+	SetCustomWords(customWords words.CustomList)
 
 	CurrentRound() int
 
@@ -29,9 +35,10 @@ type GameStatus interface {
 	PlayerOrderIDs() []string
 	SetPlayerOrderIDs(playerOrderIDs []string)
 
-	GenerateWords() []string
+	GenerateWords(seen []map[string]bool) ([]string, bool)
 	WordSelections() []string
 
+	SetDeadline(deadline time.Time)
 	SetTimeRemaining(seconds int)
 
 	SetWinners(winners []model.Winner)
@@ -48,15 +55,20 @@ type Status struct {
 	currentWord    words.GameWord
 	playerOrderIDs []string
 	turnIndex      int
-	wordHistory    map[string]bool
+	wordHistory    *words.History
+	customWords    words.CustomList
+
+	// rng is the room's source of randomness, which is only used from the game state processor's goroutine
+	rng *rand.Rand
 
 	// Ephemeral
+	deadline        time.Time
 	timeLeftSeconds int
 	wordSelections  []string
 	winners         []model.Winner
 }
 
-func NewGameStatus(gameSettings settings.GameSettings) GameStatus {
+func NewGameStatus(gameSettings settings.GameSettings, rng *rand.Rand) GameStatus {
 	return &Status{
 		// required fields
 		settings:       gameSettings,
@@ -66,7 +78,8 @@ func NewGameStatus(gameSettings settings.GameSettings) GameStatus {
 		currentWord:    words.GameWord{},
 		playerOrderIDs: nil,
 		turnIndex:      0,
-		wordHistory:    make(map[string]bool),
+		wordHistory:    words.NewHistory(),
+		rng:            rng,
 
 		// initialize temp storage variables
 		timeLeftSeconds: 0,
@@ -75,7 +88,7 @@ func NewGameStatus(gameSettings settings.GameSettings) GameStatus {
 	}
 }
 
-func (s *Status) Summary(selfUserIsCurrentTurn bool) model.GameStateSummary {
+func (s *Status) Summary(selfUserIsCurrentTurn bool, now time.Time) model.GameStateSummary {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -89,10 +102,16 @@ func (s *Status) Summary(selfUserIsCurrentTurn bool) model.GameStateSummary {
 		word = ""
 	}
 
+	timeLeftSeconds := s.timeLeftSeconds
+	if !s.deadline.IsZero() {
+		timeLeftSeconds = events.SecondsLeft(s.deadline, now)
+	}
+
 	return model.GameStateSummary{
 		Settings:       s.settings,
 		Round:          s.currentRound,
-		TimeLeft:       s.timeLeftSeconds,
+		TimeLeft:       timeLeftSeconds,
+		Deadline:       events.UnixMillis(s.deadline),
 		Status:         s.status,
 		TurnStatus:     s.turnStatus,
 		PlayerOrderIDs: s.playerOrderIDs,
@@ -101,10 +120,25 @@ func (s *Status) Summary(selfUserIsCurrentTurn bool) model.GameStateSummary {
 			WordLength:     s.currentWord.WordLength(),
 			WordSelections: wordSelections,
 		},
-		Winners: s.winners,
+		Winners:     s.winners,
+		CustomWords: s.customWords.Summary(false),
 	}
 }
 
+func (s *Status) CustomWords() words.CustomList {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.customWords
+}
+
+func (s *Status) SetCustomWords(customWords words.CustomList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.customWords = customWords
+}
+
 func (s *Status) Settings() settings.GameSettings {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -112,6 +146,13 @@ func (s *Status) Settings() settings.GameSettings {
 	return s.settings
 }
 
+func (s *Status) SetSettings(gameSettings settings.GameSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings = gameSettings
+}
+
 func (s *Status) CurrentRound() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -159,7 +200,7 @@ func (s *Status) SetCurrentWord(word words.GameWord) {
 	defer s.mu.Unlock()
 
 	s.currentWord = word
-	s.wordHistory[word.Word()] = true
+	s.wordHistory.Record(word.Word())
 }
 
 func (s *Status) CurrentTurnID() string {
@@ -200,24 +241,20 @@ func (s *Status) SetPlayerOrderIDs(playerOrderIDs []string) {
 	s.turnIndex = 0
 }
 
-func (s *Status) GenerateWords() []string {
+// GenerateWords picks the words offered to the drawer, preferring words which have not been drawn in the room and
+// have been seen by the fewest players in other rooms. Returns true as well if the room has run out of words which
+// have not been drawn, and so words are being repeated.
+func (s *Status) GenerateWords(seen []map[string]bool) ([]string, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	tempHistory := make(map[string]bool)
-
-	var w []string
-	for len(w) < settings.MaxSelectableWords {
-		word := words.GenerateWord()
-		if !s.wordHistory[word] && !tempHistory[word] {
-			w = append(w, word)
-			tempHistory[word] = true
-		}
-	}
+	w, exhausted := words.Default().GenerateWords(
+		s.settings.Words, s.customWords, s.settings.MaxSelectableWords, s.wordHistory, seen, s.rng,
+	)
 
 	s.wordSelections = w
 
-	return w
+	return w, exhausted
 }
 
 func (s *Status) WordSelections() []string {
@@ -227,10 +264,21 @@ func (s *Status) WordSelections() []string {
 	return s.wordSelections
 }
 
+// SetDeadline sets the deadline of the current turn phase
+func (s *Status) SetDeadline(deadline time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadline = deadline
+	s.timeLeftSeconds = 0
+}
+
+// SetTimeRemaining sets the time left in the current turn phase while its countdown is paused, clearing the deadline
 func (s *Status) SetTimeRemaining(seconds int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.deadline = time.Time{}
 	s.timeLeftSeconds = seconds
 }
 
@@ -255,6 +303,7 @@ func (s *Status) Reset() {
 	// Do not reset word history for players in the same room
 
 	// initialize temp storage variables
+	s.deadline = time.Time{}
 	s.timeLeftSeconds = 0
 	s.wordSelections = nil
 	s.winners = nil
@@ -1,17 +1,61 @@
 package state
 
 import (
+	"time"
+
+	"github.com/kvnxiao/pictorio/game/guess"
+	"github.com/kvnxiao/pictorio/game/hint"
 	"github.com/kvnxiao/pictorio/model"
+	"github.com/kvnxiao/pictorio/words"
 )
 
-type SelectionIndex struct {
-	User      model.User
-	Timestamp int64
-	Value     int
-}
+// turnPhase is a state of the game loop's state machine
+type turnPhase int
+
+const (
+	// phaseIdle is when no game is in progress, i.e. players are readying up or the game is over
+	phaseIdle turnPhase = iota
+	// phaseNextPlayer announces the next drawer
+	phaseNextPlayer
+	// phaseWordSelection waits for the drawer to select a word
+	phaseWordSelection
+	// phaseDrawing waits for players to guess the word, or for the drawing time to run out
+	phaseDrawing
+	// phaseTurnEnd reveals the word before moving on to the next turn
+	phaseTurnEnd
+)
+
+// turn is the state of the current turn, which is only ever read or written by the event processor's goroutine
+type turn struct {
+	phase turnPhase
+
+	// drawer is the current turn's drawer
+	drawer model.User
+
+	// maxTimeSeconds is the duration of the current phase, which ends at the deadline
+	maxTimeSeconds int
+	deadline       time.Time
+
+	// wordSelections are the words the drawer may choose from during phaseWordSelection
+	wordSelections []string
+
+	// word is the word being drawn during phaseDrawing
+	word words.GameWord
+
+	// difficulty is the difficulty of the word being drawn
+	difficulty words.Difficulty
+
+	// guesses records which players have guessed the word during phaseDrawing, and scorer awards points for them
+	guesses *guess.PlayerGuesses
+	scorer  guess.Scorer
+
+	// hints generates hints for the word during phaseDrawing, until the first player guesses the word
+	hints      *hint.Hint
+	hintsSent  []model.Hint
+	firstGuess bool
 
-type Guess struct {
-	User      model.User
-	Timestamp int64
-	Value     string
+	// paused is set during phaseDrawing while the drawer is disconnected, in which case the phase timer waits for the
+	// drawer's grace period instead, and remaining holds the time that was left until the deadline
+	paused    bool
+	remaining time.Duration
 }
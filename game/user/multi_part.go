@@ -0,0 +1,239 @@
+package user
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+	"github.com/kvnxiao/pictorio/events"
+)
+
+const (
+	defaultPartSize           = 16 * 1024
+	defaultMaxPayloadSize     = 1024 * 1024
+	defaultMaxPendingPayloads = 8
+	defaultPayloadTimeout     = 10 * time.Second
+	defaultMaxMessageSize     = 64 * 1024
+
+	// minPartSize is the minimum size of every part of an incoming payload except the last, which bounds the number of
+	// parts that a payload may be split into
+	minPartSize = 1024
+)
+
+// MultiPartOptions configures how large events are split into, and reassembled from, multi-part payloads on every
+// user connection
+type MultiPartOptions struct {
+	// PartSize is the maximum size of an outgoing message, above which the message is split into parts of this size
+	PartSize int
+	// MaxMessageSize is the maximum size of an incoming message, above which the connection is closed. Larger events
+	// must be sent in multiple parts.
+	MaxMessageSize int
+	// MaxPayloadSize is the maximum number of bytes buffered for incomplete incoming payloads on a connection
+	MaxPayloadSize int
+	// MaxPendingPayloads is the maximum number of incomplete incoming payloads on a connection
+	MaxPendingPayloads int
+	// Timeout is the maximum time allowed to receive every part of an incoming payload
+	Timeout time.Duration
+}
+
+var (
+	multiPartOptionsMu sync.RWMutex
+	multiPartOptions   = MultiPartOptions{
+		PartSize:           defaultPartSize,
+		MaxMessageSize:     defaultMaxMessageSize,
+		MaxPayloadSize:     defaultMaxPayloadSize,
+		MaxPendingPayloads: defaultMaxPendingPayloads,
+		Timeout:            defaultPayloadTimeout,
+	}
+)
+
+// ConfigureMultiPart sets the multi-part payload options used for new user connections. Zero values fall back to the
+// defaults.
+func ConfigureMultiPart(opts MultiPartOptions) {
+	if opts.PartSize <= 0 {
+		opts.PartSize = defaultPartSize
+	}
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = defaultMaxMessageSize
+	}
+	if opts.MaxPayloadSize <= 0 {
+		opts.MaxPayloadSize = defaultMaxPayloadSize
+	}
+	if opts.MaxPendingPayloads <= 0 {
+		opts.MaxPendingPayloads = defaultMaxPendingPayloads
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultPayloadTimeout
+	}
+
+	multiPartOptionsMu.Lock()
+	defer multiPartOptionsMu.Unlock()
+
+	multiPartOptions = opts
+}
+
+func getMultiPartOptions() MultiPartOptions {
+	multiPartOptionsMu.RLock()
+	defer multiPartOptionsMu.RUnlock()
+
+	return multiPartOptions
+}
+
+// partialPayload is an incoming payload which is still missing some of its parts
+type partialPayload struct {
+	parts    [][]byte
+	received int
+	size     int
+	expires  time.Time
+}
+
+// assembler reassembles the incoming multi-part payloads of a single connection. Parts are added by the connection's
+// ReaderLoop, while incomplete payloads are expired by the assembler's own run loop.
+type assembler struct {
+	mu      sync.Mutex
+	options MultiPartOptions
+	clock   clock.Clock
+	pending map[string]*partialPayload
+	// size is the number of bytes buffered across all pending payloads, which is limited by MaxPayloadSize
+	size int
+	// started is notified when a new payload starts pending, so that the run loop waits for it to expire
+	started chan struct{}
+}
+
+func newAssembler(options MultiPartOptions, clk clock.Clock) *assembler {
+	return &assembler{
+		options: options,
+		clock:   clk,
+		pending: make(map[string]*partialPayload),
+		started: make(chan struct{}, 1),
+	}
+}
+
+// run discards incomplete payloads once they have timed out, without waiting for another message to be read from the
+// connection, until the context is done. onExpire is called with the payload ID of every payload discarded.
+func (a *assembler) run(ctx context.Context, onExpire func(payloadID string)) {
+	for {
+		var timer clock.Timer
+		var timeout <-chan time.Time
+		if expires, ok := a.nextExpiry(); ok {
+			timer = a.clock.NewTimer(expires.Sub(a.clock.Now()))
+			timeout = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-a.started:
+		case <-timeout:
+			for _, payloadID := range a.expire() {
+				onExpire(payloadID)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// nextExpiry returns the time at which the next pending payload times out, or false if no payloads are pending
+func (a *assembler) nextExpiry() (time.Time, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var next time.Time
+	for _, payload := range a.pending {
+		if next.IsZero() || payload.expires.Before(next) {
+			next = payload.expires
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// add buffers a part of a payload, returning the reassembled payload once every part has been received. The whole
+// payload is discarded if the part is rejected.
+func (a *assembler) add(part events.MultiPartPayloadEvent) ([]byte, *events.ActionError) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	payload, ok := a.pending[part.PayloadID]
+	if !ok {
+		maxParts := (a.options.MaxPayloadSize + minPartSize - 1) / minPartSize
+		if part.Total <= 0 || part.Total > maxParts {
+			return nil, events.Reject(events.ErrorMalformedEvent, "Invalid number of parts: "+strconv.Itoa(part.Total))
+		}
+		if len(a.pending) >= a.options.MaxPendingPayloads {
+			return nil, events.Reject(events.ErrorPayloadTooLarge, "Too many incomplete payloads")
+		}
+		payload = &partialPayload{
+			parts:   make([][]byte, part.Total),
+			expires: a.clock.Now().Add(a.options.Timeout),
+		}
+		a.pending[part.PayloadID] = payload
+		select {
+		case a.started <- struct{}{}:
+		default:
+		}
+	}
+
+	if part.Total != len(payload.parts) || part.Part < 0 || part.Part >= len(payload.parts) ||
+		payload.parts[part.Part] != nil || (part.Part < part.Total-1 && len(part.Data) < minPartSize) {
+		a.discard(part.PayloadID)
+		return nil, events.Reject(events.ErrorMalformedEvent, "Invalid or duplicate part "+strconv.Itoa(part.Part))
+	}
+	if a.size+len(part.Data) > a.options.MaxPayloadSize {
+		a.discard(part.PayloadID)
+		return nil, events.Reject(events.ErrorPayloadTooLarge,
+			"Payload exceeds "+strconv.Itoa(a.options.MaxPayloadSize)+" bytes")
+	}
+
+	// Distinguish an empty part from a missing one
+	data := part.Data
+	if data == nil {
+		data = []byte{}
+	}
+	payload.parts[part.Part] = data
+	payload.received++
+	payload.size += len(data)
+	a.size += len(data)
+
+	if payload.received < len(payload.parts) {
+		return nil, nil
+	}
+
+	a.discard(part.PayloadID)
+	assembled := make([]byte, 0, payload.size)
+	for _, data := range payload.parts {
+		assembled = append(assembled, data...)
+	}
+	return assembled, nil
+}
+
+// expire discards the payloads which have not been completed in time, returning their payload IDs
+func (a *assembler) expire() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.clock.Now()
+	var expired []string
+	for payloadID, payload := range a.pending {
+		if !now.Before(payload.expires) {
+			expired = append(expired, payloadID)
+		}
+	}
+	for _, payloadID := range expired {
+		a.discard(payloadID)
+	}
+	return expired
+}
+
+// discard must be called while holding the lock
+func (a *assembler) discard(payloadID string) {
+	if payload, ok := a.pending[payloadID]; ok {
+		a.size -= payload.size
+		delete(a.pending, payloadID)
+	}
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kvnxiao/pictorio/clock"
+	"github.com/kvnxiao/pictorio/events"
+)
+
+var testMultiPartOptions = MultiPartOptions{
+	PartSize:           minPartSize,
+	MaxMessageSize:     defaultMaxMessageSize,
+	MaxPayloadSize:     4 * minPartSize,
+	MaxPendingPayloads: 2,
+	Timeout:            10 * time.Second,
+}
+
+func part(payloadID string, index int, total int, size int) events.MultiPartPayloadEvent {
+	return events.MultiPartPayloadEvent{
+		PayloadID: payloadID,
+		Part:      index,
+		Total:     total,
+		Data:      bytes.Repeat([]byte{byte('a' + index)}, size),
+	}
+}
+
+func TestAssemblerReassemblesParts(t *testing.T) {
+	a := newAssembler(testMultiPartOptions, clock.NewManual(time.Unix(0, 0)))
+
+	// Parts may arrive out of order
+	if assembled, err := a.add(part("p", 1, 2, 10)); assembled != nil || err != nil {
+		t.Fatalf("assembled %d bytes with error %v from the first part", len(assembled), err)
+	}
+	assembled, err := a.add(part("p", 0, 2, minPartSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(part("p", 0, 2, minPartSize).Data, part("p", 1, 2, 10).Data...)
+	if !bytes.Equal(assembled, expected) {
+		t.Errorf("assembled %q, expected %q", assembled, expected)
+	}
+	if a.size != 0 || len(a.pending) != 0 {
+		t.Errorf("%d bytes of %d payloads remain buffered", a.size, len(a.pending))
+	}
+}
+
+func TestAssemblerLimitsBufferedBytes(t *testing.T) {
+	a := newAssembler(testMultiPartOptions, clock.NewManual(time.Unix(0, 0)))
+
+	// Neither payload exceeds the limit by itself, but both together do
+	for i := 0; i < 3; i++ {
+		if _, err := a.add(part("p", i, 4, minPartSize)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := a.add(part("q", 0, 2, 2*minPartSize))
+	if err == nil || err.Code != events.ErrorPayloadTooLarge {
+		t.Fatalf("buffered more than %d bytes, with error %v", testMultiPartOptions.MaxPayloadSize, err)
+	}
+	if _, ok := a.pending["q"]; ok || a.size != 3*minPartSize {
+		t.Errorf("rejected payload is still buffered, along with %d bytes", a.size)
+	}
+}
+
+func TestAssemblerExpiresIncompletePayloads(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	a := newAssembler(testMultiPartOptions, clk)
+	expired := make(chan string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.run(ctx, func(payloadID string) {
+		expired <- payloadID
+	})
+
+	if _, err := a.add(part("p", 0, 2, minPartSize)); err != nil {
+		t.Fatal(err)
+	}
+	// The payload expires without any further parts being added, once the run loop is waiting for it
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Timers() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	clk.Advance(testMultiPartOptions.Timeout)
+
+	select {
+	case payloadID := <-expired:
+		if payloadID != "p" {
+			t.Errorf("expired payload %q, expected p", payloadID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("incomplete payload did not expire")
+	}
+	if _, ok := a.nextExpiry(); ok {
+		t.Errorf("expired payload is still pending")
+	}
+}
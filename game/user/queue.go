@@ -0,0 +1,122 @@
+package user
+
+import (
+	"errors"
+	"expvar"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueuePolicy decides what happens when a message is sent to a user whose outgoing queue is full
+type QueuePolicy int
+
+const (
+	// PolicyDisconnect evicts users who fall too far behind by closing their connection
+	PolicyDisconnect QueuePolicy = iota
+	// PolicyDropNewest drops messages that do not fit into the user's outgoing queue
+	PolicyDropNewest
+)
+
+const (
+	defaultQueueSize    = 256
+	defaultWriteTimeout = 10 * time.Second
+)
+
+// QueueOptions configures the outgoing message queue of every user connection
+type QueueOptions struct {
+	// Size is the maximum number of messages waiting to be written to a connection
+	Size int
+	// Policy is applied when a connection's queue is full
+	Policy QueuePolicy
+	// WriteTimeout is the maximum time allowed to write a single message to a connection
+	WriteTimeout time.Duration
+}
+
+var (
+	optionsMu sync.RWMutex
+	options   = QueueOptions{
+		Size:         defaultQueueSize,
+		Policy:       PolicyDisconnect,
+		WriteTimeout: defaultWriteTimeout,
+	}
+)
+
+// ParseQueuePolicy parses a queue policy by name, which is either "disconnect" or "drop"
+func ParseQueuePolicy(name string) (QueuePolicy, error) {
+	switch strings.ToLower(name) {
+	case "disconnect":
+		return PolicyDisconnect, nil
+	case "drop":
+		return PolicyDropNewest, nil
+	default:
+		return PolicyDisconnect, errors.New("unknown queue policy: " + name)
+	}
+}
+
+// Configure sets the queue options used for new user connections. Zero values fall back to the defaults.
+func Configure(opts QueueOptions) {
+	if opts.Size <= 0 {
+		opts.Size = defaultQueueSize
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaultWriteTimeout
+	}
+
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+
+	options = opts
+}
+
+func queueOptions() QueueOptions {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+
+	return options
+}
+
+// Metrics on outgoing queues across all connections, published under "outbound" in /debug/vars
+var (
+	metrics = expvar.NewMap("outbound")
+
+	// queueDepth is the total number of messages waiting to be written across all connections
+	queueDepth = new(expvar.Int)
+	// maxQueueDepth is the deepest that any single connection's queue has been
+	maxQueueDepth   = new(expvar.Int)
+	maxQueueDepthMu sync.Mutex
+	// coalesced counts messages that replaced a queued message of the same kind
+	coalesced = new(expvar.Int)
+	// dropped counts messages that were dropped due to a full queue
+	dropped = new(expvar.Int)
+	// evicted counts connections that were closed due to a full queue
+	evicted = new(expvar.Int)
+	// writeTimeouts counts writes to a connection that did not complete in time
+	writeTimeouts = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("queueDepth", queueDepth)
+	metrics.Set("maxQueueDepth", maxQueueDepth)
+	metrics.Set("coalesced", coalesced)
+	metrics.Set("dropped", dropped)
+	metrics.Set("evicted", evicted)
+	metrics.Set("writeTimeouts", writeTimeouts)
+}
+
+func recordQueueDepth(depth int) {
+	maxQueueDepthMu.Lock()
+	defer maxQueueDepthMu.Unlock()
+
+	if int64(depth) > maxQueueDepth.Value() {
+		maxQueueDepth.Set(int64(depth))
+	}
+}
+
+// outgoingMessage is a message waiting to be written to a user's connection. Messages with the same non-empty
+// coalesceKey supersede each other, so that only the most recent one is written.
+type outgoingMessage struct {
+	coalesceKey string
+	// parts are written in order, and hold a single part unless the message was split into a multi-part payload
+	parts [][]byte
+}
@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/model"
+	"nhooyr.io/websocket"
+)
+
+// newTestUser returns a user whose connection is served by a test server, with the provided queue size and policy,
+// along with the client's end of the connection
+func newTestUser(t *testing.T, size int, policy QueuePolicy) (*User, *websocket.Conn) {
+	t.Helper()
+
+	accepted := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.Dial(context.Background(), strings.Replace(server.URL, "http", "ws", 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := NewUser(<-accepted, model.User{ID: "user", Name: "user"}, events.Handshake{Version: events.ProtocolVersion})
+	u.options = QueueOptions{Size: size, Policy: policy, WriteTimeout: defaultWriteTimeout}
+	t.Cleanup(func() {
+		// Both ends are closed at once, as each waits for the other to reply to its close frame
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			_ = u.Close(websocket.StatusNormalClosure, "")
+		}()
+		_ = client.Close(websocket.StatusNormalClosure, "")
+		<-closed
+	})
+	return u, client
+}
+
+// queued returns the messages waiting to be written to the user's connection, in order
+func queued(u *User) []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var msgs []string
+	for _, msg := range u.outgoing {
+		msgs = append(msgs, string(msg.parts[0]))
+	}
+	return msgs
+}
+
+// counter reports how much an expvar counter has changed since it was created
+func counter(value func() int64) func() int64 {
+	start := value()
+	return func() int64 {
+		return value() - start
+	}
+}
+
+func send(t *testing.T, u *User, msg string, coalesceKey string, expected bool) {
+	t.Helper()
+
+	if ok := u.Send([]byte(msg), coalesceKey); ok != expected {
+		t.Fatalf("queued %q: %v, expected %v", msg, ok, expected)
+	}
+}
+
+func expectQueued(t *testing.T, u *User, expected ...string) {
+	t.Helper()
+
+	if msgs := queued(u); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("queued %q, expected %q", msgs, expected)
+	}
+}
+
+func TestSendCoalescesByKey(t *testing.T) {
+	u, _ := newTestUser(t, 3, PolicyDropNewest)
+	depth := counter(queueDepth.Value)
+	coalescedCount := counter(coalesced.Value)
+
+	send(t, u, "a", "", true)
+	send(t, u, "countdown 3", "countdown", true)
+	send(t, u, "b", "", true)
+	// The superseded countdown is removed, and the new one is queued at the tail after b
+	send(t, u, "countdown 2", "countdown", true)
+	expectQueued(t, u, "a", "b", "countdown 2")
+
+	// Coalescing frees a slot, so a message that supersedes another is queued even if the queue is full
+	send(t, u, "countdown 1", "countdown", true)
+	expectQueued(t, u, "a", "b", "countdown 1")
+	send(t, u, "c", "", false)
+	// Messages with a different key are not coalesced
+	send(t, u, "timer", "timer", false)
+	expectQueued(t, u, "a", "b", "countdown 1")
+
+	if n := coalescedCount(); n != 2 {
+		t.Errorf("coalesced %d messages, expected 2", n)
+	}
+	if n := depth(); n != 3 {
+		t.Errorf("queue depth increased by %d, expected 3", n)
+	}
+	if n := u.QueueDepth(); n != 3 {
+		t.Errorf("queue depth is %d, expected 3", n)
+	}
+}
+
+func TestSendDropsNewestWhenFull(t *testing.T) {
+	u, _ := newTestUser(t, 2, PolicyDropNewest)
+	depth := counter(queueDepth.Value)
+	droppedCount := counter(dropped.Value)
+	evictedCount := counter(evicted.Value)
+
+	send(t, u, "a", "", true)
+	send(t, u, "b", "", true)
+	send(t, u, "c", "", false)
+	send(t, u, "d", "", false)
+	expectQueued(t, u, "a", "b")
+	if maxQueueDepth.Value() < 2 {
+		t.Errorf("max queue depth is %d, expected at least 2", maxQueueDepth.Value())
+	}
+
+	// Dequeuing a message makes room for the next one
+	if parts, ok := u.dequeue(); !ok || string(parts[0]) != "a" {
+		t.Fatalf("dequeued %q, expected a", parts)
+	}
+	send(t, u, "e", "", true)
+	expectQueued(t, u, "b", "e")
+
+	if n := droppedCount(); n != 2 {
+		t.Errorf("dropped %d messages, expected 2", n)
+	}
+	if n := evictedCount(); n != 0 {
+		t.Errorf("evicted %d users, expected none", n)
+	}
+	if n := depth(); n != 2 {
+		t.Errorf("queue depth increased by %d, expected 2", n)
+	}
+}
+
+func TestSendEvictsWhenFull(t *testing.T) {
+	u, client := newTestUser(t, 2, PolicyDisconnect)
+	depth := counter(queueDepth.Value)
+	droppedCount := counter(dropped.Value)
+	evictedCount := counter(evicted.Value)
+
+	send(t, u, "a", "", true)
+	send(t, u, "b", "", true)
+	send(t, u, "c", "", false)
+
+	// Evicting the user discards every queued message, and no more messages are accepted
+	expectQueued(t, u)
+	send(t, u, "d", "", false)
+	if n := evictedCount(); n != 1 {
+		t.Errorf("evicted %d users, expected 1", n)
+	}
+	if n := droppedCount(); n != 0 {
+		t.Errorf("dropped %d messages, expected none", n)
+	}
+	if n := depth(); n != 0 {
+		t.Errorf("queue depth changed by %d after eviction, expected 0", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := client.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Errorf("connection was not closed for a policy violation: %v", err)
+	}
+}
@@ -2,30 +2,88 @@ package user
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"sync"
 
+	"github.com/kvnxiao/pictorio/clock"
+	"github.com/kvnxiao/pictorio/codec"
+	"github.com/kvnxiao/pictorio/ctxs"
+	"github.com/kvnxiao/pictorio/events"
 	"github.com/kvnxiao/pictorio/model"
 	"github.com/rs/zerolog/log"
 	"nhooyr.io/websocket"
 )
 
 type User struct {
-	outgoing chan []byte
-	conn     *websocket.Conn
-	ID       string
-	Name     string
+	// mu guards the outgoing queue
+	mu       sync.Mutex
+	outgoing []outgoingMessage
+	// notify signals the WriterLoop that messages have been queued
+	notify chan struct{}
+	// closed is true once the connection no longer accepts messages, either from being evicted for falling too far
+	// behind or after the WriterLoop has stopped
+	closed  bool
+	options QueueOptions
+	// nextPayloadID numbers the multi-part payloads sent to the connection
+	nextPayloadID uint64
+
+	multiPart MultiPartOptions
+
+	// codec encodes and decodes the connection's messages, as negotiated by the connection's subprotocol
+	codec codec.Codec
+	// handshake is the protocol version and capabilities declared by the user's client
+	handshake events.Handshake
+
+	conn *websocket.Conn
+	ID   string
+	Name string
 }
 
-func NewUser(conn *websocket.Conn, player model.User) *User {
+// Message is a raw message read from a user's WebSocket connection, tagged with the ID of the user that the connection
+// was authenticated as. Event handlers must use UserID as the sender rather than any user provided in the payload.
+type Message struct {
+	UserID string
+	// Event is the event read from the connection, whose payload is decoded with Codec
+	Event events.GameEvent
+	Codec codec.Codec
+
+	// From is the connection the message was read from, which replies are sent to
+	From *User
+}
+
+func NewUser(conn *websocket.Conn, player model.User, handshake events.Handshake) *User {
 	return &User{
-		outgoing: make(chan []byte),
-		conn:     conn,
-		ID:       player.ID,
-		Name:     player.Name,
+		outgoing:  nil,
+		notify:    make(chan struct{}, 1),
+		options:   queueOptions(),
+		multiPart: getMultiPartOptions(),
+		codec:     codec.ForConnection(conn.Subprotocol(), handshake),
+		handshake: handshake,
+		conn:      conn,
+		ID:        player.ID,
+		Name:      player.Name,
 	}
 }
 
 // ReaderLoop represents the read-loop that continuously ingests new messages from a user's WebSocket connection.
-func (p *User) ReaderLoop(ctx context.Context, messageQueue chan<- []byte, connErrChan chan<- error) {
+func (p *User) ReaderLoop(ctx context.Context, messageQueue chan<- Message, connErrChan chan<- error) {
+	userKSUID, ok := ctxs.UserID(ctx)
+	if !ok {
+		connErrChan <- errors.New("could not get user ID from connection context")
+		return
+	}
+	userID := userKSUID.String()
+	p.conn.SetReadLimit(int64(p.multiPart.MaxMessageSize))
+
+	payloads := newAssembler(p.multiPart, clock.Real())
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go payloads.run(ctx, func(payloadID string) {
+		expired := events.Reject(events.ErrorPayloadExpired, "Timed out waiting for every part")
+		p.reject(payloadID, events.MultiPartPayload, expired)
+	})
+
 	for {
 		_, readBytes, err := p.conn.Read(ctx)
 		if err != nil {
@@ -37,25 +95,94 @@ func (p *User) ReaderLoop(ctx context.Context, messageQueue chan<- []byte, connE
 			Bytes("msg", readBytes).
 			Str("id", p.ID).
 			Msg("Received message from user")
-		messageQueue <- readBytes
+
+		event, ok := p.decode(payloads, readBytes)
+		if !ok {
+			continue
+		}
+		messageQueue <- Message{
+			UserID: userID,
+			Event:  event,
+			Codec:  p.codec,
+			From:   p,
+		}
+	}
+}
+
+// decode decodes the envelope of a message read from the connection, reassembling multi-part payloads. Returns false
+// if the message was not decoded into a complete event.
+func (p *User) decode(payloads *assembler, msg []byte) (events.GameEvent, bool) {
+	event, err := p.codec.Decode(msg)
+	if err != nil {
+		p.reject("", 0, events.Reject(events.ErrorMalformedEvent, err.Error()))
+		return events.GameEvent{}, false
+	}
+	return p.reassemble(payloads, event)
+}
+
+// reassemble buffers the parts of multi-part payloads, returning the reassembled event once every part of a payload
+// has been received. Any other event is returned as is. Rejected payloads are acknowledged as such.
+func (p *User) reassemble(payloads *assembler, event events.GameEvent) (events.GameEvent, bool) {
+	if event.Type != events.MultiPartPayload {
+		return event, true
+	}
+
+	var part events.MultiPartPayloadEvent
+	if err := p.codec.Unmarshal(event.Data, &part); err != nil {
+		p.reject("", event.Type, events.Reject(events.ErrorMalformedEvent, "Could not unmarshal "+event.Type.String()))
+		return events.GameEvent{}, false
+	}
+	assembled, actionErr := payloads.add(part)
+	if actionErr != nil {
+		p.reject(part.PayloadID, event.Type, actionErr)
+		return events.GameEvent{}, false
+	}
+	if assembled == nil {
+		return events.GameEvent{}, false
 	}
+
+	// The parts of a multi-part payload carry an event encoded with the connection's codec
+	assembledEvent, err := p.codec.Decode(assembled)
+	if err != nil {
+		p.reject(part.PayloadID, event.Type, events.Reject(events.ErrorMalformedEvent, err.Error()))
+		return events.GameEvent{}, false
+	}
+	return assembledEvent, true
+}
+
+func (p *User) reject(id string, eventType events.GameEventType, actionErr *events.ActionError) {
+	log.Warn().
+		Str("uid", p.ID).
+		Str("id", id).
+		Str("code", string(actionErr.Code)).
+		Msg(actionErr.Message)
+	p.SendEvent(events.Nack(id, eventType, actionErr), 0)
 }
 
-// WriterLoop represents the write-loop that continuously ingests messages queued into the user's outgoing message
-// channel and writes to the user's WebSocket connection.
+// WriterLoop represents the write-loop that continuously writes messages queued into the user's outgoing message
+// queue to the user's WebSocket connection. Each write must complete within the configured write timeout.
 func (p *User) WriterLoop(ctx context.Context, connErrChan chan error) {
+	defer func() {
+		p.mu.Lock()
+		p.discard()
+		p.mu.Unlock()
+	}()
+
 	for {
 		select {
-		case msg := <-p.outgoing:
-			err := p.conn.Write(ctx, websocket.MessageText, msg)
-			if err != nil {
-				connErrChan <- err
-				return
+		case <-p.notify:
+			for {
+				msg, ok := p.dequeue()
+				if !ok {
+					break
+				}
+				for _, part := range msg {
+					if err := p.write(ctx, part); err != nil {
+						connErrChan <- err
+						return
+					}
+				}
 			}
-			log.Debug().
-				Bytes("msg", msg).
-				Str("uid", p.ID).
-				Msg("Wrote message to user")
 		case <-ctx.Done():
 			log.Debug().Msg("Done WriterLoop!")
 			return
@@ -63,7 +190,146 @@ func (p *User) WriterLoop(ctx context.Context, connErrChan chan error) {
 	}
 }
 
-// Outgoing returns the writable []byte channel that can be used to send messages to this specific player
-func (p *User) Outgoing() chan<- []byte {
-	return p.outgoing
+func (p *User) write(ctx context.Context, msg []byte) error {
+	writeCtx, cancel := context.WithTimeout(ctx, p.options.WriteTimeout)
+	defer cancel()
+
+	messageType := websocket.MessageText
+	if p.codec.Binary() {
+		messageType = websocket.MessageBinary
+	}
+	err := p.conn.Write(writeCtx, messageType, msg)
+	if err != nil {
+		if errors.Is(writeCtx.Err(), context.DeadlineExceeded) {
+			writeTimeouts.Add(1)
+			log.Warn().Str("uid", p.ID).Msg("Timed out writing message to user")
+		}
+		return err
+	}
+	log.Debug().
+		Bytes("msg", msg).
+		Str("uid", p.ID).
+		Msg("Wrote message to user")
+	return nil
+}
+
+func (p *User) dequeue() ([][]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.outgoing) == 0 {
+		return nil, false
+	}
+	msg := p.outgoing[0]
+	p.outgoing[0] = outgoingMessage{}
+	p.outgoing = p.outgoing[1:]
+	queueDepth.Add(-1)
+	return msg.parts, true
+}
+
+// Send queues a message to be written to the user's connection without blocking. A non-empty coalesceKey removes any
+// queued message with the same key, and the message is queued at the tail, which is used for unsequenced messages that
+// supersede each other, such as countdowns. If the queue is full, the message is either dropped or the user is evicted
+// depending on the queue policy. Returns whether the message was queued. Messages larger than the configured part size
+// are split into a multi-part payload if the user's client can reassemble them, which is queued as a single message.
+func (p *User) Send(msg []byte, coalesceKey string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+
+	if coalesceKey != "" {
+		for i, queued := range p.outgoing {
+			if queued.coalesceKey == coalesceKey {
+				// Remove the superseded message, as the new one is queued at the tail after any messages queued since
+				p.outgoing = append(p.outgoing[:i], p.outgoing[i+1:]...)
+				queueDepth.Add(-1)
+				coalesced.Add(1)
+				break
+			}
+		}
+	}
+
+	if len(p.outgoing) >= p.options.Size {
+		switch p.options.Policy {
+		case PolicyDropNewest:
+			dropped.Add(1)
+			log.Debug().Str("uid", p.ID).Msg("Outgoing queue is full, dropping message")
+		default:
+			p.evict()
+		}
+		return false
+	}
+
+	parts := [][]byte{msg}
+	if len(msg) > p.multiPart.PartSize && p.handshake.Has(events.CapabilityMultiPart) {
+		p.nextPayloadID++
+		payloadID := strconv.FormatUint(p.nextPayloadID, 10)
+		parts = parts[:0]
+		for _, part := range events.SplitPayload(payloadID, msg, p.multiPart.PartSize) {
+			parts = append(parts, p.codec.Encode(part, 0))
+		}
+	}
+
+	p.outgoing = append(p.outgoing, outgoingMessage{
+		coalesceKey: coalesceKey,
+		parts:       parts,
+	})
+	queueDepth.Add(1)
+	recordQueueDepth(len(p.outgoing))
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// SendEvent encodes an event with the connection's codec and queues it to be written to the user's connection
+func (p *User) SendEvent(event events.SerializableEvent, seq uint64) bool {
+	return p.Send(p.codec.Encode(event, seq), events.CoalesceKey(event, seq))
+}
+
+// Codec returns the codec which encodes the messages sent to the user's connection
+func (p *User) Codec() codec.Codec {
+	return p.codec
+}
+
+// evict closes the connection of a user who has fallen too far behind, and must be called while holding the lock
+func (p *User) evict() {
+	p.discard()
+	evicted.Add(1)
+
+	log.Warn().Str("uid", p.ID).Msg("Outgoing queue is full, evicting slow user")
+	go func() {
+		_ = p.conn.Close(websocket.StatusPolicyViolation, "too slow to receive messages")
+	}()
+}
+
+// discard stops accepting messages and drops all queued messages, and must be called while holding the lock
+func (p *User) discard() {
+	p.closed = true
+	queueDepth.Add(int64(-len(p.outgoing)))
+	p.outgoing = nil
+}
+
+// QueueDepth returns the number of messages waiting to be written to the user's connection
+func (p *User) QueueDepth() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.outgoing)
+}
+
+// QueueSize returns the maximum number of messages waiting to be written to the user's connection
+func (p *User) QueueSize() int {
+	return p.options.Size
+}
+
+// Close closes the user's WebSocket connection with the provided status code and reason, which stops both the
+// ReaderLoop and WriterLoop.
+func (p *User) Close(code websocket.StatusCode, reason string) error {
+	return p.conn.Close(code, reason)
 }
@@ -4,9 +4,12 @@ go 1.15
 
 require (
 	github.com/dchest/uniuri v0.0.0-20200228104902-7aecb25e1fe5
+	github.com/fxamacker/cbor/v2 v2.5.0
 	github.com/go-chi/chi v1.5.1
 	github.com/go-chi/cors v1.1.1
+	github.com/rivo/uniseg v0.2.0
 	github.com/rs/zerolog v1.20.0
 	github.com/segmentio/ksuid v1.0.3
+	golang.org/x/text v0.3.6
 	nhooyr.io/websocket v1.8.7-0.20200705210220-897a573291be
 )
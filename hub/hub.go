@@ -1,10 +1,12 @@
 package hub
 
 import (
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/kvnxiao/pictorio/game"
+	"github.com/kvnxiao/pictorio/model"
 	"github.com/rs/zerolog/log"
 )
 
@@ -31,16 +33,16 @@ func (h *Hub) generateUniqueID() string {
 	}
 }
 
-func (h *Hub) NewRoom() *game.Room {
-	roomID := h.generateUniqueID()
-	r := game.NewRoom(roomID)
+func (h *Hub) NewRoom(opts game.RoomOptions) *game.Room {
 	h.roomMu.Lock()
+	roomID := h.generateUniqueID()
+	r := game.NewRoom(roomID, opts)
 	h.rooms[roomID] = r
 	h.roomMu.Unlock()
 
 	go h.roomCleanupListener(r)
 
-	log.Info().Str("roomID", roomID).Msg("Creating new room")
+	log.Info().Str("roomID", roomID).Bool("public", opts.Public).Int64("seed", r.Seed()).Msg("Creating new room")
 
 	return r
 }
@@ -70,3 +72,30 @@ func (h *Hub) Remove(roomID string) {
 	delete(h.rooms, roomID)
 	h.roomMu.Unlock()
 }
+
+// PublicRooms returns listings of all public rooms, newest first. If joinableOnly is true, rooms that cannot be joined
+// as a player are excluded. The hub's lock is only held while copying the list of rooms, not while querying each room.
+func (h *Hub) PublicRooms(joinableOnly bool) []model.RoomListing {
+	h.roomMu.Lock()
+	rooms := make([]*game.Room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		if r.IsPublic() {
+			rooms = append(rooms, r)
+		}
+	}
+	h.roomMu.Unlock()
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt().After(rooms[j].CreatedAt())
+	})
+
+	listings := make([]model.RoomListing, 0, len(rooms))
+	for _, r := range rooms {
+		listing, ok := r.Listing()
+		if !ok || (joinableOnly && !listing.Joinable) {
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	return listings
+}
@@ -2,20 +2,70 @@ package main
 
 import (
 	"flag"
-	"math/rand"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/kvnxiao/pictorio/clock"
+	"github.com/kvnxiao/pictorio/cookies"
+	"github.com/kvnxiao/pictorio/game"
+	"github.com/kvnxiao/pictorio/game/state/drawing"
+	"github.com/kvnxiao/pictorio/game/user"
 	"github.com/kvnxiao/pictorio/service"
+	"github.com/kvnxiao/pictorio/words"
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 func main() {
 	var hostFlag = flag.String("host", ":3000", "The hostname to start the server on")
 	var debugFlag = flag.Bool("debug", false, "Enables debug mode for logging")
+	var cookieSecretsFlag = flag.String(
+		"cookie-secrets",
+		os.Getenv("PICTORIO_COOKIE_SECRETS"),
+		"Comma-separated secrets used to sign session cookies, newest first. "+
+			"Older secrets are only used to verify existing cookies. Defaults to $PICTORIO_COOKIE_SECRETS",
+	)
+	var cookieEncryptFlag = flag.Bool("cookie-encrypt", false, "Encrypts session cookie values")
+	var cookieSecureFlag = flag.Bool("cookie-secure", false, "Only sends session cookies over HTTPS")
+	var sendQueueSizeFlag = flag.Int("send-queue-size", 256, "Maximum number of messages queued for each connection")
+	var sendQueuePolicyFlag = flag.String(
+		"send-queue-policy",
+		"disconnect",
+		"What to do when a connection's send queue is full: \"disconnect\" the client, or \"drop\" the message",
+	)
+	var writeTimeoutFlag = flag.Duration("write-timeout", 10*time.Second, "Maximum time to write a message to a client")
+	var partSizeFlag = flag.Int("part-size", 16*1024, "Messages larger than this many bytes are sent in multiple parts")
+	var maxMessageSizeFlag = flag.Int(
+		"max-message-size",
+		64*1024,
+		"Maximum size in bytes of a message from a client, above which the client is disconnected",
+	)
+	var maxPayloadSizeFlag = flag.Int(
+		"max-payload-size",
+		1024*1024,
+		"Maximum number of bytes buffered for incomplete multi-part payloads from each connection",
+	)
+	var payloadTimeoutFlag = flag.Duration(
+		"payload-timeout",
+		10*time.Second,
+		"Maximum time to receive every part of a multi-part payload",
+	)
+	var maxStrokePointsFlag = flag.Int("max-stroke-points", 1024, "Maximum number of points in a line of a drawing")
+	var maxStrokesFlag = flag.Int("max-strokes", 256, "Maximum number of lines in the drawing of a turn")
+	var cookieMaxAgeFlag = flag.Duration("cookie-max-age", 365*24*time.Hour, "How long session cookies are valid for")
+	var timeScaleFlag = flag.Float64("time-scale", 1, "Runs the game loop of every room this many times faster")
+	var wordsDirFlag = flag.String(
+		"words-dir",
+		words.DefaultDir,
+		"The directory to load word packs from, relative to the executable or else the working directory",
+	)
+	var wordHistoryDirFlag = flag.String(
+		"word-history-dir",
+		"",
+		"The directory to persist the words seen by each user to, kept in memory if empty",
+	)
+	var seedFlag = flag.Int64("seed", 0, "Seeds every room's randomness to reproduce games, random per room if 0")
 
 	flag.Parse()
 
@@ -25,6 +75,63 @@ func main() {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
 
+	if err := words.Configure(*wordsDirFlag); err != nil {
+		log.Fatal().Err(err).Str("dir", *wordsDirFlag).Msg("Unable to load word packs")
+	}
+
+	if *wordHistoryDirFlag != "" {
+		store, err := words.NewFileHistoryStore(*wordHistoryDirFlag)
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", *wordHistoryDirFlag).Msg("Unable to open word history directory")
+		}
+		words.ConfigureHistory(store)
+	}
+
+	var secrets [][]byte
+	for _, secret := range strings.Split(*cookieSecretsFlag, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, []byte(secret))
+		}
+	}
+	err := cookies.Configure(cookies.Options{
+		Secrets: secrets,
+		Encrypt: *cookieEncryptFlag,
+		Secure:  *cookieSecureFlag,
+		MaxAge:  *cookieMaxAgeFlag,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to configure session cookies")
+	}
+
+	queuePolicy, err := user.ParseQueuePolicy(*sendQueuePolicyFlag)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid send queue policy")
+	}
+	user.Configure(user.QueueOptions{
+		Size:         *sendQueueSizeFlag,
+		Policy:       queuePolicy,
+		WriteTimeout: *writeTimeoutFlag,
+	})
+	user.ConfigureMultiPart(user.MultiPartOptions{
+		PartSize:       *partSizeFlag,
+		MaxMessageSize: *maxMessageSizeFlag,
+		MaxPayloadSize: *maxPayloadSizeFlag,
+		Timeout:        *payloadTimeoutFlag,
+	})
+
+	drawing.Configure(drawing.Limits{
+		MaxStrokePoints: *maxStrokePointsFlag,
+		MaxStrokes:      *maxStrokesFlag,
+	})
+
+	if *timeScaleFlag <= 0 {
+		log.Fatal().Float64("timeScale", *timeScaleFlag).Msg("Time scale must be positive")
+	}
+	game.Configure(game.Options{
+		Clock: clock.NewScaled(*timeScaleFlag),
+		Seed:  *seedFlag,
+	})
+
 	server := service.NewService()
 	server.
 		SetupMiddleware().
@@ -0,0 +1,20 @@
+package model
+
+// CustomWordsMode is how a room's custom words are drawn from
+type CustomWordsMode string
+
+const (
+	// CustomWordsMixed draws words from both the custom words and the room's word packs
+	CustomWordsMixed CustomWordsMode = "mixed"
+	// CustomWordsOnly draws words from the custom words instead of the room's word packs
+	CustomWordsOnly CustomWordsMode = "only"
+)
+
+// CustomWordsSummary describes the custom word list uploaded by a room's leader. The words are omitted if they are
+// hidden from the players.
+type CustomWordsSummary struct {
+	Count  int             `json:"count"`
+	Mode   CustomWordsMode `json:"mode"`
+	Hidden bool            `json:"hidden"`
+	Words  []string        `json:"words,omitempty"`
+}
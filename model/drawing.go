@@ -1,5 +1,13 @@
 package model
 
+const (
+	// PaletteSize is the number of colours in the drawing palette, which Line.ColourIdx indexes
+	PaletteSize = 16
+	// ThicknessCount is the number of brush thicknesses, which Line.ThicknessIdx indexes
+	ThicknessCount = 5
+)
+
+// Point is a point on the canvas in normalized canvas space, where both coordinates range from 0 to 1
 type Point struct {
 	X float64 `json:"x"`
 	Y float64 `json:"y"`
@@ -1,7 +1,10 @@
 package model
 
+// Hint reveals a character of the word being drawn. CharIndex counts the characters that a user perceives, which may
+// each consist of several runes, so Text is the whole character while Char is only its first rune.
 type Hint struct {
-	Char      rune `json:"char"`
-	WordIndex int  `json:"wordIndex"`
-	CharIndex int  `json:"charIndex"`
+	Char      rune   `json:"char"`
+	Text      string `json:"text"`
+	WordIndex int    `json:"wordIndex"`
+	CharIndex int    `json:"charIndex"`
 }
@@ -0,0 +1,8 @@
+package model
+
+// PointsBreakdown is a part of the points awarded for a correct guess, and the reason that it was awarded for
+type PointsBreakdown struct {
+	Reason        string `json:"reason"`
+	GuesserPoints int    `json:"guesserPoints"`
+	DrawerPoints  int    `json:"drawerPoints"`
+}
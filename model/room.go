@@ -1,10 +1,69 @@
 package model
 
+import (
+	"github.com/kvnxiao/pictorio/game/settings"
+)
+
 type RoomRequest struct {
 	RoomID string `json:"roomID"`
 }
 
 type RoomResponse struct {
-	RoomID string `json:"roomID"`
-	Exists bool   `json:"exists"`
+	RoomID           string `json:"roomID"`
+	Exists           bool   `json:"exists"`
+	RequiresPasscode bool   `json:"requiresPasscode"`
+}
+
+type RoomCreateRequest struct {
+	Public   bool   `json:"public"`
+	Passcode string `json:"passcode"`
+}
+
+type RoomVerifyRequest struct {
+	Passcode string `json:"passcode"`
+}
+
+// RoomVerifyResponse contains a short-lived join token, to be presented when connecting to the room's WebSocket if the
+// passcode was valid
+type RoomVerifyResponse struct {
+	RoomID    string `json:"roomID"`
+	Valid     bool   `json:"valid"`
+	Token     string `json:"token,omitempty"`
+	ExpiresAt int64  `json:"expiresAt,omitempty"`
+}
+
+// RoomListing is the publicly visible summary of a room shown in the lobby browser
+type RoomListing struct {
+	RoomID      string     `json:"roomID"`
+	PlayerCount int        `json:"playerCount"`
+	MaxPlayers  int        `json:"maxPlayers"`
+	Status      GameStatus `json:"status"`
+	Round       int        `json:"round"`
+	LeaderName  string     `json:"leaderName"`
+	Joinable    bool       `json:"joinable"`
+
+	RequiresPasscode bool `json:"requiresPasscode"`
+}
+
+type RoomListResponse struct {
+	Rooms    []RoomListing `json:"rooms"`
+	Total    int           `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+}
+
+// RoomSummary describes the state of a room to a client that has not joined it yet. It must never contain secret game
+// state such as the current word.
+type RoomSummary struct {
+	RoomID     string                `json:"roomID"`
+	Status     GameStatus            `json:"status"`
+	TurnStatus TurnStatus            `json:"turnStatus"`
+	Round      int                   `json:"round"`
+	Settings   settings.GameSettings `json:"settings"`
+	Players    []PlayerState         `json:"players"`
+	Spectators []PlayerState         `json:"spectators"`
+	MaxPlayers int                   `json:"maxPlayers"`
+	Joinable   bool                  `json:"joinable"`
+
+	RequiresPasscode bool `json:"requiresPasscode"`
 }
@@ -14,6 +14,7 @@ type GameStateSummary struct {
 	Settings settings.GameSettings `json:"settings"`
 	Round    int                   `json:"round"`
 	TimeLeft int                   `json:"timeLeft"`
+	Deadline int64                 `json:"deadline,omitempty"`
 
 	Status     GameStatus `json:"status"`
 	TurnStatus TurnStatus `json:"turnStatus"`
@@ -21,6 +22,9 @@ type GameStateSummary struct {
 	PlayerOrderIDs []string    `json:"playerOrderIds"`
 	WordSummary    WordSummary `json:"words"`
 	Winners        []Winner    `json:"winners"`
+
+	// CustomWords is omitted if the room leader has not uploaded any custom words
+	CustomWords *CustomWordsSummary `json:"customWords,omitempty"`
 }
 
 type PlayersSummary struct {
@@ -3,6 +3,14 @@ package random
 import (
 	"math/rand"
 	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// rngMu guards rng, as names are generated concurrently by HTTP handlers
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
 )
 
 var adjectives = []string{
@@ -14,8 +22,11 @@ var nouns = []string{
 }
 
 func GenerateName() string {
-	adjective := adjectives[rand.Intn(len(adjectives))]
-	noun := nouns[rand.Intn(len(nouns))]
+	rngMu.Lock()
+	defer rngMu.Unlock()
+
+	adjective := adjectives[rng.Intn(len(adjectives))]
+	noun := nouns[rng.Intn(len(nouns))]
 	return adjective + " " + noun
 }
 
@@ -7,6 +7,7 @@ import (
 	"regexp"
 
 	"github.com/go-chi/chi"
+	"github.com/kvnxiao/pictorio/cookies"
 	"github.com/kvnxiao/pictorio/ctxs"
 )
 
@@ -33,6 +34,28 @@ func (s *Service) roomIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// roomAccessMiddleware is an http middleware that refuses requests to a room unless the room does not require a
+// passcode, the user has already been admitted to the room, or a valid join token is provided in the "token" query
+// parameter. It must be used after the roomIDMiddleware.
+func (s *Service) roomAccessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roomID, _ := ctxs.RoomID(r.Context())
+		ro, ok := s.hub.Room(roomID)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		// A missing or invalid cookie leaves the user ID empty, which is never admitted
+		userID, _ := cookies.GetUserID(r)
+		if !ro.Authorize(userID, r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // validateID validates the room id according to the roomIDRegex.
 func validateID(roomID string) error {
 	if !roomIDRegex.MatchString(roomID) {
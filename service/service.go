@@ -2,19 +2,40 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"expvar"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/kvnxiao/pictorio/api"
+	"github.com/kvnxiao/pictorio/cookies"
 	"github.com/kvnxiao/pictorio/ctxs"
+	"github.com/kvnxiao/pictorio/events"
+	"github.com/kvnxiao/pictorio/game"
+	"github.com/kvnxiao/pictorio/game/state"
+	"github.com/kvnxiao/pictorio/game/state/access"
 	"github.com/kvnxiao/pictorio/hub"
 	"github.com/kvnxiao/pictorio/model"
 	"github.com/kvnxiao/pictorio/response"
 	"github.com/kvnxiao/pictorio/service/users"
+	"github.com/kvnxiao/pictorio/words"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// maxCustomWordsBodySize is the maximum size of a request to upload a room's custom words
+	maxCustomWordsBodySize = 64 * 1024
+)
+
 type Service struct {
 	hub    *hub.Hub
 	router chi.Router
@@ -37,6 +58,8 @@ func (s *Service) SetupMiddleware() *Service {
 }
 
 func (s *Service) RegisterRoutes() *Service {
+	s.router.Handle(api.Metrics, expvar.Handler())
+
 	s.router.Get(api.Name, func(w http.ResponseWriter, r *http.Request) {
 		nameResp, err := users.ReadName(w, r)
 		if err != nil {
@@ -73,8 +96,23 @@ func (s *Service) RegisterRoutes() *Service {
 	})
 
 	s.router.Post(api.RoomCreate, func(w http.ResponseWriter, r *http.Request) {
-		ro := s.hub.NewRoom()
-		if err := response.Json(w, model.RoomResponse{RoomID: ro.ID(), Exists: true}, http.StatusOK); err != nil {
+		// The request body is optional, and rooms are private by default
+		var createReq model.RoomCreateRequest
+		err := json.NewDecoder(r.Body).Decode(&createReq)
+		if (err != nil && err != io.EOF) || !access.ValidPasscode(createReq.Passcode) {
+			respErr := response.Json(w, model.RoomResponse{Exists: false}, http.StatusBadRequest)
+			if respErr != nil {
+				log.Error().Err(respErr).Msg("Unable to encode JSON response")
+			}
+			return
+		}
+
+		ro := s.hub.NewRoom(game.RoomOptions{
+			Public:   createReq.Public,
+			Passcode: createReq.Passcode,
+		})
+		roomResp := model.RoomResponse{RoomID: ro.ID(), Exists: true, RequiresPasscode: ro.RequiresPasscode()}
+		if err := response.Json(w, roomResp, http.StatusOK); err != nil {
 			log.Error().Err(err).Msg("Unable to encode JSON response")
 		}
 	})
@@ -92,8 +130,55 @@ func (s *Service) RegisterRoutes() *Service {
 		}
 
 		// Check if room id exists
-		_, ok := s.hub.Room(roomReq.RoomID)
-		respErr := response.Json(w, model.RoomResponse{RoomID: roomReq.RoomID, Exists: ok}, http.StatusOK)
+		ro, ok := s.hub.Room(roomReq.RoomID)
+		roomResp := model.RoomResponse{RoomID: roomReq.RoomID, Exists: ok}
+		if ok {
+			roomResp.RequiresPasscode = ro.RequiresPasscode()
+		}
+		respErr := response.Json(w, roomResp, http.StatusOK)
+		if respErr != nil {
+			log.Error().Err(respErr).Msg("Unable to encode JSON response")
+		}
+	})
+
+	s.router.Get(api.Rooms, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		joinableOnly := query.Get("joinable") == "true"
+		page := queryInt(query, "page", 1, 1, math.MaxInt32)
+		pageSize := queryInt(query, "pageSize", defaultPageSize, 1, maxPageSize)
+
+		listings := s.hub.PublicRooms(joinableOnly)
+
+		// Paginate listings, with pages starting at 1
+		start := (page - 1) * pageSize
+		if start > len(listings) {
+			start = len(listings)
+		}
+		end := start + pageSize
+		if end > len(listings) {
+			end = len(listings)
+		}
+
+		respErr := response.Json(w, model.RoomListResponse{
+			Rooms:    listings[start:end],
+			Total:    len(listings),
+			Page:     page,
+			PageSize: pageSize,
+		}, http.StatusOK)
+		if respErr != nil {
+			log.Error().Err(respErr).Msg("Unable to encode JSON response")
+		}
+	})
+
+	s.router.Get(api.Schema, func(w http.ResponseWriter, r *http.Request) {
+		respErr := response.Json(w, events.Schema(), http.StatusOK)
+		if respErr != nil {
+			log.Error().Err(respErr).Msg("Unable to encode JSON response")
+		}
+	})
+
+	s.router.Get(api.WordPacks, func(w http.ResponseWriter, r *http.Request) {
+		respErr := response.Json(w, words.Default().Packs(), http.StatusOK)
 		if respErr != nil {
 			log.Error().Err(respErr).Msg("Unable to encode JSON response")
 		}
@@ -111,7 +196,96 @@ func (s *Service) RegisterRoutes() *Service {
 					}
 					return
 				}
+
+				ro, ok := s.hub.Room(roomID)
+				if !ok {
+					if err := response.Json(w, model.RoomResponse{RoomID: roomID}, http.StatusNotFound); err != nil {
+						log.Error().Err(err).Str("route", "/room/"+roomID).Msg("Unable to encode JSON response")
+					}
+					return
+				}
+				summary, ok := ro.Summary()
+				if !ok {
+					if err := response.Json(w, model.RoomResponse{RoomID: roomID}, http.StatusNotFound); err != nil {
+						log.Error().Err(err).Str("route", "/room/"+roomID).Msg("Unable to encode JSON response")
+					}
+					return
+				}
+
+				if err := response.Json(w, summary, http.StatusOK); err != nil {
+					log.Error().Err(err).Str("route", "/room/"+roomID).Msg("Unable to encode JSON response")
+				}
+			})
+			r.Post("/verify", func(w http.ResponseWriter, r *http.Request) {
+				roomID, _ := ctxs.RoomID(r.Context())
+				ro, ok := s.hub.Room(roomID)
+				if !ok {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+
+				var verifyReq model.RoomVerifyRequest
+				if err := json.NewDecoder(r.Body).Decode(&verifyReq); err != nil {
+					respErr := response.Json(w, model.RoomVerifyResponse{RoomID: roomID}, http.StatusBadRequest)
+					if respErr != nil {
+						log.Error().Err(respErr).Msg("Unable to encode JSON response")
+					}
+					return
+				}
+
+				verifyResp := model.RoomVerifyResponse{RoomID: roomID}
+				status := http.StatusForbidden
+				token, expiry, err := ro.VerifyPasscode(verifyReq.Passcode, clientAddress(r))
+				if err == nil {
+					verifyResp.Valid = true
+					verifyResp.Token = token
+					verifyResp.ExpiresAt = expiry.Unix()
+					status = http.StatusOK
+				} else if errors.Is(err, access.ErrTooManyAttempts) {
+					status = http.StatusTooManyRequests
+				}
+				if err := response.Json(w, verifyResp, status); err != nil {
+					log.Error().Err(err).Msg("Unable to encode JSON response")
+				}
+			})
+			r.With(s.roomAccessMiddleware).Put("/words", func(w http.ResponseWriter, r *http.Request) {
+				roomID, _ := ctxs.RoomID(r.Context())
+				ro, ok := s.hub.Room(roomID)
+				if !ok {
+					http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+					return
+				}
+
+				// The request body is the payload of a CustomWordsEvent, which is handled as if it was sent over the
+				// user's WebSocket connection
+				var data json.RawMessage
+				if err := json.NewDecoder(io.LimitReader(r.Body, maxCustomWordsBodySize)).Decode(&data); err != nil {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				userID, _ := cookies.GetUserID(r)
+				err := ro.HandleEvent(userID, events.GameEvent{Type: events.EventTypeCustomWords, Data: data})
+				if err == state.ErrRoomClosed {
+					http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+					return
+				}
+
+				ack := events.Ack("", events.EventTypeCustomWords)
+				status := http.StatusOK
+				var actionErr *events.ActionError
+				if errors.As(err, &actionErr) {
+					ack = events.Nack("", events.EventTypeCustomWords, actionErr)
+					status = actionErrorStatus(actionErr.Code)
+				} else if err != nil {
+					log.Error().Err(err).Str("route", "/room/"+roomID+"/words").Msg("Unable to set custom words")
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+				if err := response.Json(w, ack, status); err != nil {
+					log.Error().Err(err).Msg("Unable to encode JSON response")
+				}
 			})
+			// The room authorizes the connection itself, once it has read or assigned the user's ID
 			r.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 				ctx := r.Context()
 				roomID, ok := ctxs.RoomID(ctx)
@@ -138,3 +312,41 @@ func (s *Service) Serve(addr string) {
 		log.Fatal().Err(err)
 	}
 }
+
+// queryInt parses an integer query parameter, falling back to the default value if the parameter is missing or
+// invalid, and clamping the result to the provided bounds.
+func queryInt(query url.Values, key string, defaultValue int, min int, max int) int {
+	value, err := strconv.Atoi(query.Get(key))
+	if err != nil {
+		return defaultValue
+	}
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// actionErrorStatus maps the reason for an event being rejected to the HTTP status of the response.
+func actionErrorStatus(code events.ErrorCode) int {
+	switch code {
+	case events.ErrorNotInRoom, events.ErrorNotRoomLeader:
+		return http.StatusForbidden
+	case events.ErrorInvalidGameStatus:
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// clientAddress returns the IP address of the client that sent a request, which the RealIP middleware takes from the
+// proxy headers if present.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -11,28 +11,33 @@ import (
 	"github.com/segmentio/ksuid"
 )
 
+// Read reads the user's unique ID and name from their session cookies. A new identity is generated and set on the
+// response if the user has no session, or if the session cookies fail verification.
 func Read(w http.ResponseWriter, req *http.Request) (ksuid.KSUID, string, error) {
 	// Read user's unique ID or generate one if not exist
-
 	userID, err := cookies.GetUserID(req)
-	if err != nil || userID == "" {
-		randomID, err := ksuid.NewRandom()
-		if err != nil {
-			return ksuid.KSUID{}, "", errors.New("could not generate a unique ID for a new user")
-		}
-		userID = randomID.String()
-		cookies.SetUserID(w, userID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejecting invalid user ID cookie, issuing a new identity")
+		userID = ""
 	}
 
 	// Ensure user ID parsed from cookies is of expected type
 	userKSUID, err := ksuid.Parse(userID)
-	if err != nil {
-		return ksuid.KSUID{}, "", errors.New("could not parse user ID")
+	isNewIdentity := userID == "" || err != nil
+	if isNewIdentity {
+		userKSUID, err = ksuid.NewRandom()
+		if err != nil {
+			return ksuid.KSUID{}, "", errors.New("could not generate a unique ID for a new user")
+		}
+		cookies.SetUserID(w, userKSUID.String())
 	}
 
-	// Read user name
+	// Read user name, which is regenerated along with a new identity
 	userName, err := cookies.GetUserName(req)
-	if err != nil || userName == "" {
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejecting invalid user name cookie")
+	}
+	if isNewIdentity || err != nil || userName == "" {
 		log.Debug().Msg("Generating random name for new user")
 		userName = random.GenerateName()
 		cookies.SetUserName(w, userName)
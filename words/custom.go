@@ -0,0 +1,87 @@
+package words
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/kvnxiao/pictorio/model"
+)
+
+const (
+	// MaxCustomWords is the maximum number of words in a room's custom word list
+	MaxCustomWords = 500
+	// MaxCustomWordLength is the maximum number of characters in a custom word
+	MaxCustomWordLength = 32
+)
+
+// CustomList is a word list uploaded by a room's leader
+type CustomList struct {
+	Words  []string
+	Mode   model.CustomWordsMode
+	Hidden bool
+}
+
+// NewCustomList validates a custom word list, normalizing the spacing of its words and removing duplicate words. A
+// word may only contain letters, digits, spaces, hyphens and apostrophes.
+func NewCustomList(words []string, mode model.CustomWordsMode, hidden bool) (CustomList, error) {
+	switch mode {
+	case model.CustomWordsMixed, model.CustomWordsOnly:
+	default:
+		return CustomList{}, fmt.Errorf("unknown custom words mode %q", mode)
+	}
+	if len(words) > MaxCustomWords {
+		return CustomList{}, fmt.Errorf("at most %d custom words can be used, got %d", MaxCustomWords, len(words))
+	}
+
+	list := CustomList{Mode: mode, Hidden: hidden}
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		word = strings.Join(strings.Fields(word), " ")
+		if word == "" {
+			continue
+		}
+		if Length(word) > MaxCustomWordLength {
+			return CustomList{}, fmt.Errorf("custom word %q must not be longer than %d characters",
+				word, MaxCustomWordLength)
+		}
+		for _, r := range word {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.In(r, unicode.Mn, unicode.Mc) &&
+				r != ' ' && r != '-' && r != '\'' {
+				return CustomList{}, fmt.Errorf("custom word %q must only contain letters, digits, spaces, "+
+					"hyphens and apostrophes", word)
+			}
+		}
+		if !seen[normalize(word)] {
+			list.Words = append(list.Words, word)
+			seen[normalize(word)] = true
+		}
+	}
+	return list, nil
+}
+
+// Contains returns true if a word is in the custom word list
+func (c CustomList) Contains(word string) bool {
+	for _, w := range c.Words {
+		if normalize(w) == normalize(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the custom word list to the players of the room, including its words if they are not hidden
+func (c CustomList) Summary(includeHidden bool) *model.CustomWordsSummary {
+	if len(c.Words) == 0 {
+		return nil
+	}
+	summary := &model.CustomWordsSummary{
+		Count:  len(c.Words),
+		Mode:   c.Mode,
+		Hidden: c.Hidden,
+	}
+	if !c.Hidden || includeHidden {
+		summary.Words = c.Words
+	}
+	return summary
+}
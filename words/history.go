@@ -0,0 +1,401 @@
+package words
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// History records when words were last drawn in a room, so that the least recently drawn words are drawn first. It is
+// not safe for concurrent use.
+type History struct {
+	lastDrawn map[string]uint64
+	// turn counts the words recorded, and orders them from least to most recently drawn
+	turn uint64
+}
+
+func NewHistory() *History {
+	return &History{lastDrawn: make(map[string]uint64)}
+}
+
+// Record records that a word has just been drawn
+func (h *History) Record(word string) {
+	h.turn++
+	h.lastDrawn[normalize(word)] = h.turn
+}
+
+// LastDrawn returns when a word was last drawn relative to the other words in the history, or false if the word has
+// never been drawn
+func (h *History) LastDrawn(word string) (uint64, bool) {
+	turn, ok := h.lastDrawn[normalize(word)]
+	return turn, ok
+}
+
+// HistoryStore remembers the words that users have seen across every room, so that users are not offered the same
+// words in each room they play in. A store is shared by every room, and so must be safe for concurrent use. Its
+// methods are called from the game loops of rooms, and so must not block on I/O.
+type HistoryStore interface {
+	// Preload starts loading the history of a user who has joined a room, for stores which persist histories
+	Preload(userID string)
+	// Seen returns the normalized words that the user has seen
+	Seen(userID string) map[string]bool
+	// Record records that the users have seen a word
+	Record(userIDs []string, word string)
+}
+
+const (
+	defaultMaxHistoryUsers = 10000
+	// maxUserHistoryWords is the number of most recently seen words remembered for each user
+	maxUserHistoryWords = 1000
+)
+
+// userHistory is the words seen by a user, from least to most recently seen
+type userHistory struct {
+	userID string
+	words  []string
+}
+
+func (u *userHistory) record(word string) {
+	for i, w := range u.words {
+		if w == word {
+			u.words = append(u.words[:i], u.words[i+1:]...)
+			break
+		}
+	}
+	u.words = append(u.words, word)
+	if len(u.words) > maxUserHistoryWords {
+		u.words = u.words[len(u.words)-maxUserHistoryWords:]
+	}
+}
+
+// MemoryHistoryStore keeps the word histories of the most recently active users in memory, which outlives the rooms
+// that the users play in but not the server
+type MemoryHistoryStore struct {
+	mu       sync.Mutex
+	maxUsers int
+	// users orders the histories from least to most recently used
+	users  *list.List
+	byUser map[string]*list.Element
+}
+
+// NewMemoryHistoryStore creates a store which remembers the word histories of up to maxUsers users, forgetting the
+// least recently active users first. Non-positive values fall back to the default of 10000 users.
+func NewMemoryHistoryStore(maxUsers int) *MemoryHistoryStore {
+	if maxUsers <= 0 {
+		maxUsers = defaultMaxHistoryUsers
+	}
+	return &MemoryHistoryStore{
+		maxUsers: maxUsers,
+		users:    list.New(),
+		byUser:   make(map[string]*list.Element),
+	}
+}
+
+// Preload does nothing, as the store does not persist histories
+func (s *MemoryHistoryStore) Preload(string) {}
+
+func (s *MemoryHistoryStore) Seen(userID string) map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	if element, ok := s.byUser[userID]; ok {
+		for _, word := range element.Value.(*userHistory).words {
+			seen[word] = true
+		}
+	}
+	return seen
+}
+
+func (s *MemoryHistoryStore) Record(userIDs []string, word string) {
+	s.record(userIDs, word)
+}
+
+// record records that the users have seen a word, and returns a copy of each user's history
+func (s *MemoryHistoryStore) record(userIDs []string, word string) map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	histories := make(map[string][]string, len(userIDs))
+	for _, userID := range userIDs {
+		history := s.history(userID)
+		history.record(normalize(word))
+		histories[userID] = append([]string(nil), history.words...)
+	}
+	return histories
+}
+
+// history returns a user's history, marking the user as the most recently active. It must be called with the mutex
+// held.
+func (s *MemoryHistoryStore) history(userID string) *userHistory {
+	if element, ok := s.byUser[userID]; ok {
+		s.users.MoveToBack(element)
+		return element.Value.(*userHistory)
+	}
+
+	history := &userHistory{userID: userID}
+	s.byUser[userID] = s.users.PushBack(history)
+	for s.users.Len() > s.maxUsers {
+		oldest := s.users.Front()
+		s.users.Remove(oldest)
+		delete(s.byUser, oldest.Value.(*userHistory).userID)
+	}
+	return history
+}
+
+// has returns true if the store holds a history for the user
+func (s *MemoryHistoryStore) has(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.byUser[userID]
+	return ok
+}
+
+// words returns a copy of a user's history, or false if the store holds no history for the user
+func (s *MemoryHistoryStore) words(userID string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.byUser[userID]
+	if !ok {
+		return nil, false
+	}
+	return append([]string(nil), element.Value.(*userHistory).words...), true
+}
+
+// merge adds the words of a user's history that was loaded from elsewhere before the words that the user has seen
+// since, which are more recent
+func (s *MemoryHistoryStore) merge(userID string, loaded []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.history(userID)
+	recent := make(map[string]bool, len(history.words))
+	for _, word := range history.words {
+		recent[word] = true
+	}
+	var words []string
+	for _, word := range loaded {
+		if !recent[word] {
+			words = append(words, word)
+		}
+	}
+	words = append(words, history.words...)
+	if len(words) > maxUserHistoryWords {
+		words = words[len(words)-maxUserHistoryWords:]
+	}
+	history.words = words
+}
+
+// userIDRegex matches the user IDs which are safe to use as file names
+var userIDRegex = regexp.MustCompile("^[a-zA-Z0-9]{1,64}$")
+
+// flushInterval is how often the FileHistoryStore writes the histories which have changed to their files
+const flushInterval = time.Second
+
+// FileHistoryStore persists the word history of each user to a JSON file in a directory, so that the histories
+// outlive the server. Histories are kept in memory, and a background goroutine loads them from their files when users
+// join a room, and writes the histories which have changed to their files in batches, so that rooms never wait on the
+// disk. A history which has not been loaded yet when a word is offered is treated as empty.
+type FileHistoryStore struct {
+	dir    string
+	memory *MemoryHistoryStore
+
+	// mu guards the loads and writes waiting for the background goroutine
+	mu sync.Mutex
+	// loads is the set of users whose histories are waiting to be loaded
+	loads map[string]bool
+	// writes holds a copy of each changed history, waiting to be written to its file, for histories which are evicted
+	// from memory before they are written
+	writes map[string][]string
+	// wake signals the background goroutine that a history is waiting to be loaded
+	wake chan struct{}
+}
+
+// NewFileHistoryStore creates a store which persists word histories to a directory, creating it if it does not exist
+func NewFileHistoryStore(dir string) (*FileHistoryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileHistoryStore{
+		dir:    dir,
+		memory: NewMemoryHistoryStore(0),
+		loads:  make(map[string]bool),
+		writes: make(map[string][]string),
+		wake:   make(chan struct{}, 1),
+	}
+	go s.run()
+	return s, nil
+}
+
+type historyFile struct {
+	Words []string `json:"words"`
+}
+
+func (s *FileHistoryStore) path(userID string) string {
+	return filepath.Join(s.dir, userID+".json")
+}
+
+// Preload queues a user's history to be loaded from its file, unless it is already in memory
+func (s *FileHistoryStore) Preload(userID string) {
+	if !userIDRegex.MatchString(userID) || s.memory.has(userID) {
+		return
+	}
+
+	s.mu.Lock()
+	s.loads[userID] = true
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *FileHistoryStore) Seen(userID string) map[string]bool {
+	return s.memory.Seen(userID)
+}
+
+func (s *FileHistoryStore) Record(userIDs []string, word string) {
+	valid := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userIDRegex.MatchString(userID) {
+			valid = append(valid, userID)
+		}
+	}
+	histories := s.memory.record(valid, word)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, words := range histories {
+		s.writes[userID] = words
+	}
+}
+
+// run loads the histories of users who have joined a room as they join, and writes the changed histories to their
+// files every flushInterval
+func (s *FileHistoryStore) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.wake:
+			s.loadPending()
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+// loadPending loads the histories waiting to be loaded into memory
+func (s *FileHistoryStore) loadPending() {
+	s.mu.Lock()
+	loads := s.loads
+	s.loads = make(map[string]bool)
+	s.mu.Unlock()
+
+	for userID := range loads {
+		words, err := s.load(userID)
+		if err != nil {
+			log.Error().Err(err).Str("uid", userID).Msg("Unable to read word history")
+			continue
+		}
+		if len(words) > 0 {
+			s.memory.merge(userID, words)
+		}
+	}
+}
+
+// load reads a user's history from its file, which is empty if the file does not exist
+func (s *FileHistoryStore) load(userID string) ([]string, error) {
+	data, err := ioutil.ReadFile(s.path(userID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.Words, nil
+}
+
+// flush writes the changed histories to their files. The histories waiting to be loaded are loaded first, so that a
+// history which changed before it was loaded does not replace the history in its file.
+func (s *FileHistoryStore) flush() {
+	s.loadPending()
+
+	s.mu.Lock()
+	writes := s.writes
+	s.writes = make(map[string][]string)
+	s.mu.Unlock()
+
+	for userID, words := range writes {
+		if current, ok := s.memory.words(userID); ok {
+			words = current
+		}
+		if err := s.save(userID, words); err != nil {
+			log.Error().Err(err).Str("uid", userID).Msg("Unable to save word history")
+		}
+	}
+}
+
+// save writes a user's history to its file, replacing the file atomically
+func (s *FileHistoryStore) save(userID string, words []string) error {
+	data, err := json.Marshal(historyFile{Words: words})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(s.dir, userID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(userID)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace word history: %w", err)
+	}
+	return nil
+}
+
+var (
+	historyStoreMu sync.RWMutex
+	historyStore   HistoryStore = NewMemoryHistoryStore(0)
+)
+
+// ConfigureHistory sets the store of the word histories of users, which is kept in memory by default
+func ConfigureHistory(store HistoryStore) {
+	historyStoreMu.Lock()
+	defer historyStoreMu.Unlock()
+
+	historyStore = store
+}
+
+// Histories returns the store of the word histories of users
+func Histories() HistoryStore {
+	historyStoreMu.RLock()
+	defer historyStoreMu.RUnlock()
+
+	return historyStore
+}
@@ -0,0 +1,72 @@
+package words
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testUserID = "1mZlaYVCTdKa1mxDE5JDGWQhyUr"
+
+func readHistoryFile(t *testing.T, dir string, userID string) []string {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, userID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	return file.Words
+}
+
+func TestFileHistoryStoreWritesOnFlush(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileHistoryStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Record([]string{testUserID, "../escape"}, "Crème Brûlée")
+	store.Record([]string{testUserID}, "apple")
+	if !store.Seen(testUserID)["creme brulee"] {
+		t.Errorf("recorded word was not seen")
+	}
+
+	store.flush()
+	if words := readHistoryFile(t, dir, testUserID); !reflect.DeepEqual(words, []string{"creme brulee", "apple"}) {
+		t.Errorf("wrote %v", words)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("wrote files %v, expected only the valid user's history", files)
+	}
+}
+
+func TestFileHistoryStoreMergesLoadedHistory(t *testing.T) {
+	dir := t.TempDir()
+	data, _ := json.Marshal(historyFile{Words: []string{"apple", "banana"}})
+	if err := ioutil.WriteFile(filepath.Join(dir, testUserID+".json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileHistoryStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Preload(testUserID)
+	// The word is seen before the history is loaded, and so is more recent than the loaded words
+	store.Record([]string{testUserID}, "apple")
+	store.flush()
+
+	if words := readHistoryFile(t, dir, testUserID); !reflect.DeepEqual(words, []string{"banana", "apple"}) {
+		t.Errorf("wrote %v", words)
+	}
+	seen := store.Seen(testUserID)
+	if !seen["apple"] || !seen["banana"] {
+		t.Errorf("loaded history was not seen: %v", seen)
+	}
+}
@@ -0,0 +1,369 @@
+package words
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kvnxiao/pictorio/game/settings"
+	"github.com/kvnxiao/pictorio/model"
+)
+
+// DefaultDir is the directory that word packs are loaded from by default, relative to the executable
+const DefaultDir = "assets/words"
+
+// parsers reads a word pack by its file extension
+var parsers = map[string]func(path string, r io.Reader) (Pack, error){
+	".json": parseJSONPack,
+	".tsv":  parseTSVPack,
+	".txt":  parseTextPack,
+}
+
+// Library is the set of word packs that rooms draw their words from. A library is not modified after it is loaded,
+// and so is safe for concurrent use.
+type Library struct {
+	// packs is sorted by name, so that words are drawn in the same order for the same seed
+	packs  []Pack
+	byName map[string]int
+	// difficulties maps the normalized words of every pack to their difficulty
+	difficulties map[string]Difficulty
+}
+
+// LoadDir loads every word pack in a directory, which are the files with a .json, .tsv or .txt extension. Returns an
+// error if any pack is invalid, or if two packs have the same name.
+func LoadDir(dir string) (*Library, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var packs []Pack
+	for _, file := range files {
+		parse, ok := parsers[strings.ToLower(filepath.Ext(file.Name()))]
+		if file.IsDir() || !ok {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		pack, err := loadPack(path, parse)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load word pack %s: %w", path, err)
+		}
+		packs = append(packs, pack)
+	}
+	return NewLibrary(packs...)
+}
+
+func loadPack(path string, parse func(path string, r io.Reader) (Pack, error)) (Pack, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Pack{}, err
+	}
+	defer f.Close()
+
+	pack, err := parse(path, f)
+	if err != nil {
+		return Pack{}, err
+	}
+	return pack, pack.validate()
+}
+
+// NewLibrary creates a library of word packs, applying the defaults of each pack to its words
+func NewLibrary(packs ...Pack) (*Library, error) {
+	library := &Library{
+		packs:        make([]Pack, len(packs)),
+		byName:       make(map[string]int, len(packs)),
+		difficulties: make(map[string]Difficulty),
+	}
+	copy(library.packs, packs)
+	sort.SliceStable(library.packs, func(i, j int) bool {
+		return library.packs[i].Name < library.packs[j].Name
+	})
+
+	for i := range library.packs {
+		pack := &library.packs[i]
+		if err := pack.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := library.byName[pack.Name]; ok {
+			return nil, fmt.Errorf("duplicate word pack %q", pack.Name)
+		}
+		library.byName[pack.Name] = i
+		for _, entry := range pack.Words {
+			if _, ok := library.difficulties[normalize(entry.Word)]; !ok {
+				library.difficulties[normalize(entry.Word)] = entry.Difficulty
+			}
+		}
+	}
+	return library, nil
+}
+
+// Difficulty returns the difficulty of a word, which is that of the first pack by name to include it. Words which are
+// not in any pack, such as custom words, are of medium difficulty.
+func (l *Library) Difficulty(word string) Difficulty {
+	if difficulty, ok := l.difficulties[normalize(word)]; ok {
+		return difficulty
+	}
+	return Medium
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if a word of a selected pack is in one of the filter's categories and difficulties, and has all
+// of its tags
+func matches(f settings.WordFilter, entry Entry) bool {
+	if len(f.Categories) > 0 && !contains(f.Categories, entry.Category) {
+		return false
+	}
+	if len(f.Difficulties) > 0 && !contains(f.Difficulties, string(entry.Difficulty)) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !entry.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the filter only names packs and difficulties which exist in the library, and that the filter
+// and custom words select at least the given number of words
+func (l *Library) Validate(f settings.WordFilter, custom CustomList, minWords int) error {
+	for _, name := range f.Packs {
+		if _, ok := l.byName[name]; !ok {
+			return fmt.Errorf("unknown word pack %q", name)
+		}
+	}
+	for _, difficulty := range f.Difficulties {
+		if !Difficulty(difficulty).valid() {
+			return fmt.Errorf("unknown word difficulty %q", difficulty)
+		}
+	}
+	if count := len(l.Candidates(f, custom)); count < minWords {
+		return fmt.Errorf("the selected words must include at least %d words, got %d", minWords, count)
+	}
+	return nil
+}
+
+// Words returns the distinct words selected by the filter
+func (l *Library) Words(f settings.WordFilter) []string {
+	var selected []string
+	seen := make(map[string]bool)
+	for _, pack := range l.packs {
+		if len(f.Packs) > 0 && !contains(f.Packs, pack.Name) {
+			continue
+		}
+		for _, entry := range pack.Words {
+			if matches(f, entry) && !seen[normalize(entry.Word)] {
+				selected = append(selected, entry.Word)
+				seen[normalize(entry.Word)] = true
+			}
+		}
+	}
+	return selected
+}
+
+// PackSummary describes a word pack to room leaders choosing the packs of a room
+type PackSummary struct {
+	Name         string       `json:"name"`
+	Description  string       `json:"description,omitempty"`
+	Words        int          `json:"words"`
+	Categories   []string     `json:"categories"`
+	Difficulties []Difficulty `json:"difficulties"`
+	Tags         []string     `json:"tags"`
+}
+
+// Packs summarizes every pack in the library, sorted by name
+func (l *Library) Packs() []PackSummary {
+	summaries := make([]PackSummary, 0, len(l.packs))
+	for _, pack := range l.packs {
+		categories := make(map[string]bool)
+		difficulties := make(map[Difficulty]bool)
+		tags := make(map[string]bool)
+		for _, entry := range pack.Words {
+			if entry.Category != "" {
+				categories[entry.Category] = true
+			}
+			difficulties[entry.Difficulty] = true
+			for _, tag := range entry.Tags {
+				tags[tag] = true
+			}
+		}
+
+		summary := PackSummary{
+			Name:         pack.Name,
+			Description:  pack.Description,
+			Words:        len(pack.Words),
+			Categories:   sortedKeys(categories),
+			Difficulties: make([]Difficulty, 0, len(difficulties)),
+			Tags:         sortedKeys(tags),
+		}
+		for _, difficulty := range Difficulties {
+			if difficulties[difficulty] {
+				summary.Difficulties = append(summary.Difficulties, difficulty)
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	libraryMu sync.RWMutex
+	library   = &Library{byName: make(map[string]int)}
+)
+
+// ResolveDir finds a word pack directory. A relative directory is resolved against the directory of the executable,
+// or the working directory if it is not found there, such as when running with go run. Returns an error if the
+// directory does not exist in either place.
+func ResolveDir(dir string) (string, error) {
+	candidates := []string{dir}
+	if !filepath.IsAbs(dir) {
+		if exe, err := os.Executable(); err == nil {
+			if exe, err = filepath.EvalSymlinks(exe); err == nil {
+				candidates = []string{filepath.Join(filepath.Dir(exe), dir), dir}
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("word pack directory %s does not exist, looked in %s", dir, strings.Join(candidates, ", "))
+}
+
+// Configure loads the word packs in a directory, resolved by ResolveDir, as the library that rooms draw their words
+// from. The library is empty until it is configured, and so an error is returned if no word packs are found.
+func Configure(dir string) error {
+	dir, err := ResolveDir(dir)
+	if err != nil {
+		return err
+	}
+	loaded, err := LoadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(loaded.packs) == 0 {
+		return fmt.Errorf("no word packs found in %s", dir)
+	}
+
+	libraryMu.Lock()
+	defer libraryMu.Unlock()
+
+	library = loaded
+	return nil
+}
+
+// Default returns the library that rooms draw their words from
+func Default() *Library {
+	libraryMu.RLock()
+	defer libraryMu.RUnlock()
+
+	return library
+}
+
+// Candidates returns the distinct words that a room draws from, which are the words selected by the filter, the
+// room's custom words, or both depending on the custom words' mode
+func (l *Library) Candidates(f settings.WordFilter, custom CustomList) []string {
+	if len(custom.Words) == 0 {
+		return l.Words(f)
+	}
+	if custom.Mode == model.CustomWordsOnly {
+		return custom.Words
+	}
+
+	candidates := append([]string(nil), custom.Words...)
+	seen := make(map[string]bool, len(custom.Words))
+	for _, word := range custom.Words {
+		seen[normalize(word)] = true
+	}
+	for _, word := range l.Words(f) {
+		if !seen[normalize(word)] {
+			candidates = append(candidates, word)
+		}
+	}
+	return candidates
+}
+
+// GenerateWords picks up to count distinct words out of the candidates for the filter and custom words, using the
+// given source of randomness. Words which have not been drawn in the room are picked first, preferring the words seen
+// by the fewest of the given players in other rooms, followed by the least recently drawn words. Fewer words are
+// returned if there are not enough candidates.
+//
+// The second return value is true if the room has run out of words which have not been drawn, so that words are
+// repeated from the least recently drawn.
+func (l *Library) GenerateWords(
+	f settings.WordFilter,
+	custom CustomList,
+	count int,
+	history *History,
+	seen []map[string]bool,
+	rng *rand.Rand,
+) ([]string, bool) {
+	candidates := l.Candidates(f, custom)
+
+	type candidate struct {
+		word      string
+		drawn     bool
+		lastDrawn uint64
+		seenBy    int
+	}
+	ranked := make([]candidate, 0, len(candidates))
+	undrawn := 0
+	// Shuffle the candidates first, so that words which are ranked equally are picked at random
+	for _, i := range rng.Perm(len(candidates)) {
+		c := candidate{word: candidates[i]}
+		c.lastDrawn, c.drawn = history.LastDrawn(c.word)
+		if !c.drawn {
+			undrawn++
+		}
+		for _, userSeen := range seen {
+			if userSeen[normalize(c.word)] {
+				c.seenBy++
+			}
+		}
+		ranked = append(ranked, c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.drawn != b.drawn {
+			return !a.drawn
+		}
+		if a.drawn {
+			return a.lastDrawn < b.lastDrawn
+		}
+		return a.seenBy < b.seenBy
+	})
+
+	if len(ranked) > count {
+		ranked = ranked[:count]
+	}
+	picked := make([]string, len(ranked))
+	for i, c := range ranked {
+		picked[i] = c.word
+	}
+	return picked, undrawn < count
+}
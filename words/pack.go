@@ -0,0 +1,195 @@
+package words
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Difficulty is how hard a word is to draw and guess
+type Difficulty string
+
+const (
+	Easy   Difficulty = "easy"
+	Medium Difficulty = "medium"
+	Hard   Difficulty = "hard"
+)
+
+// Difficulties lists every difficulty, from easiest to hardest
+var Difficulties = []Difficulty{Easy, Medium, Hard}
+
+func (d Difficulty) valid() bool {
+	switch d {
+	case Easy, Medium, Hard:
+		return true
+	default:
+		return false
+	}
+}
+
+// Entry is a word in a word pack
+type Entry struct {
+	Word       string     `json:"word"`
+	Category   string     `json:"category,omitempty"`
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+}
+
+// HasTag returns true if the entry is tagged with the given content tag
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Pack is a named collection of words. The category, difficulty and tags of a pack are the defaults of its words.
+type Pack struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Difficulty  Difficulty `json:"difficulty,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Words       []Entry    `json:"words"`
+}
+
+// normalize returns the form of a word that is compared to detect duplicates, which is the form that guesses are
+// compared in, since words which only differ in that form cannot be told apart by guessing
+func normalize(word string) string {
+	return Fold(word)
+}
+
+// validate applies the pack's defaults to its words, and checks that every word is unique within the pack and has a
+// known difficulty. Words without a difficulty are of medium difficulty.
+func (p *Pack) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("word pack must have a name")
+	}
+	if p.Difficulty == "" {
+		p.Difficulty = Medium
+	}
+	if !p.Difficulty.valid() {
+		return fmt.Errorf("word pack %q has an unknown difficulty %q", p.Name, p.Difficulty)
+	}
+	if len(p.Words) == 0 {
+		return fmt.Errorf("word pack %q has no words", p.Name)
+	}
+
+	seen := make(map[string]int, len(p.Words))
+	for i := range p.Words {
+		entry := &p.Words[i]
+		entry.Word = strings.Join(strings.Fields(entry.Word), " ")
+		if entry.Word == "" {
+			return fmt.Errorf("word pack %q has an empty word at position %d", p.Name, i+1)
+		}
+		if previous, ok := seen[normalize(entry.Word)]; ok {
+			return fmt.Errorf("word pack %q has a duplicate word %q at positions %d and %d",
+				p.Name, entry.Word, previous+1, i+1)
+		}
+		seen[normalize(entry.Word)] = i
+
+		entry.Category = strings.TrimSpace(entry.Category)
+		if entry.Category == "" {
+			entry.Category = p.Category
+		}
+		if entry.Difficulty == "" {
+			entry.Difficulty = p.Difficulty
+		}
+		if !entry.Difficulty.valid() {
+			return fmt.Errorf("word %q in word pack %q has an unknown difficulty %q",
+				entry.Word, p.Name, entry.Difficulty)
+		}
+		entry.Tags = mergeTags(p.Tags, entry.Tags)
+	}
+	return nil
+}
+
+// mergeTags returns the union of two lists of tags, ignoring blank tags
+func mergeTags(defaults []string, tags []string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range [][]string{defaults, tags} {
+		for _, tag := range list {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[tag] {
+				merged = append(merged, tag)
+				seen[tag] = true
+			}
+		}
+	}
+	return merged
+}
+
+// packName returns the name of a pack which does not name itself, which is its file name without the extension
+func packName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// parseJSONPack reads a pack encoded as a JSON Pack object
+func parseJSONPack(path string, r io.Reader) (Pack, error) {
+	var pack Pack
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&pack); err != nil {
+		return Pack{}, err
+	}
+	if pack.Name == "" {
+		pack.Name = packName(path)
+	}
+	return pack, nil
+}
+
+// parseTSVPack reads a pack of tab-separated words, one per line, with the columns word, category, difficulty and a
+// comma-separated list of tags. Every column except the word is optional. Blank lines and lines starting with # are
+// ignored.
+func parseTSVPack(path string, r io.Reader) (Pack, error) {
+	pack := Pack{Name: packName(path)}
+	err := scanLines(r, func(lineNumber int, line string) error {
+		columns := strings.Split(line, "\t")
+		if len(columns) > 4 {
+			return fmt.Errorf("line %d has %d columns, expected at most 4", lineNumber, len(columns))
+		}
+		for len(columns) < 4 {
+			columns = append(columns, "")
+		}
+		pack.Words = append(pack.Words, Entry{
+			Word:       columns[0],
+			Category:   columns[1],
+			Difficulty: Difficulty(strings.ToLower(strings.TrimSpace(columns[2]))),
+			Tags:       strings.Split(columns[3], ","),
+		})
+		return nil
+	})
+	return pack, err
+}
+
+// parseTextPack reads a pack of words, one per line. Blank lines and lines starting with # are ignored.
+func parseTextPack(path string, r io.Reader) (Pack, error) {
+	pack := Pack{Name: packName(path)}
+	err := scanLines(r, func(_ int, line string) error {
+		pack.Words = append(pack.Words, Entry{Word: line})
+		return nil
+	})
+	return pack, err
+}
+
+func scanLines(r io.Reader, fn func(lineNumber int, line string) error) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if err := fn(lineNumber, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
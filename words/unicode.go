@@ -0,0 +1,87 @@
+package words
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// Graphemes splits a string into the characters that a user perceives, which are the extended grapheme clusters of
+// Unicode and may each consist of several runes
+func Graphemes(s string) []string {
+	var graphemes []string
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		graphemes = append(graphemes, g.Str())
+	}
+	return graphemes
+}
+
+// Length returns the number of characters in a string that a user perceives
+func Length(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// combiningDiacritics are the combining marks which accent decomposed letters. The marks of other scripts, such as
+// Japanese dakuten or Devanagari vowel signs, are part of their letters and are kept.
+var combiningDiacritics = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0300, Hi: 0x036F, Stride: 1},
+		{Lo: 0x1AB0, Hi: 0x1AFF, Stride: 1},
+		{Lo: 0x1DC0, Hi: 0x1DFF, Stride: 1},
+		{Lo: 0x20D0, Hi: 0x20FF, Stride: 1},
+		{Lo: 0xFE20, Hi: 0xFE2F, Stride: 1},
+	},
+}
+
+// undecomposable replaces the letters which do not decompose into a base letter and diacritics, and ligatures, with
+// their base letters
+var undecomposable = strings.NewReplacer(
+	"ø", "o", "đ", "d", "ð", "d", "ħ", "h", "ı", "i", "ł", "l", "ŧ", "t",
+	"æ", "ae", "œ", "oe", "þ", "th",
+)
+
+// katakanaToHiragana maps katakana to the hiragana of the same sound
+func katakanaToHiragana(r rune) rune {
+	if r >= 0x30A1 && r <= 0x30F6 {
+		return r - 0x60
+	}
+	return r
+}
+
+// Fold returns the form of a string that guesses are compared in, which ignores case, diacritics, spacing, the width
+// of full-width Latin letters and digits, and whether Japanese kana are written in hiragana or katakana
+func Fold(s string) string {
+	// Transformers are stateful, so a new chain is needed for each string
+	t := transform.Chain(
+		norm.NFD,
+		runes.Remove(runes.In(combiningDiacritics)),
+		width.Fold,
+		cases.Fold(),
+		runes.Map(katakanaToHiragana),
+		norm.NFC,
+	)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	return strings.Join(strings.Fields(undecomposable.Replace(folded)), " ")
+}
+
+// isVowel returns true if a character is a Latin vowel, ignoring diacritics. Characters of other scripts are never
+// vowels, so every character of words written in those scripts may be given as a hint.
+func isVowel(char string) bool {
+	folded := Fold(char)
+	if folded == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(folded)
+	return vowelsMap[r]
+}
@@ -0,0 +1,68 @@
+package words
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := map[string]struct {
+		word      string
+		graphemes []string
+	}{
+		"ascii":                   {"cat", []string{"c", "a", "t"}},
+		"precomposed latin":       {"café", []string{"c", "a", "f", "é"}},
+		"decomposed latin":        {"cafe\u0301", []string{"c", "a", "f", "e\u0301"}},
+		"stacked diacritics":      {"ệ", []string{"ệ"}},
+		"zwj emoji":               {"👨‍👩‍👧x", []string{"👨‍👩‍👧", "x"}},
+		"skin tone modifier":      {"👋🏽👋", []string{"👋🏽", "👋"}},
+		"variation selector":      {"❤️a", []string{"❤️", "a"}},
+		"regional indicator flag": {"🇫🇷🇩🇪", []string{"🇫🇷", "🇩🇪"}},
+		"precomposed hangul":      {"한국", []string{"한", "국"}},
+		"hangul jamo":             {"\u1112\u1161\u11ab\u1100\u116e\u11a8", []string{"\u1112\u1161\u11ab", "\u1100\u116e\u11a8"}},
+		"japanese":                {"がっこう", []string{"が", "っ", "こ", "う"}},
+		"devanagari":              {"नमस्ते", []string{"न", "म", "स्", "ते"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if graphemes := Graphemes(test.word); !reflect.DeepEqual(graphemes, test.graphemes) {
+				t.Errorf("split %q into %q, expected %q", test.word, graphemes, test.graphemes)
+			}
+			if length := Length(test.word); length != len(test.graphemes) {
+				t.Errorf("length of %q is %d, expected %d", test.word, length, len(test.graphemes))
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := map[string]struct {
+		word   string
+		folded string
+	}{
+		"case":                  {"Apple Pie", "apple pie"},
+		"spacing":               {"  apple \t pie ", "apple pie"},
+		"precomposed latin":     {"Crème Brûlée", "creme brulee"},
+		"decomposed latin":      {"Cre\u0300me", "creme"},
+		"stacked diacritics":    {"Tiếng Việt", "tieng viet"},
+		"sharp s":               {"Straße", "strasse"},
+		"undecomposable letter": {"Ødegaard Łódź", "odegaard lodz"},
+		"ligature":              {"Æsop Œuvre", "aesop oeuvre"},
+		"full-width":            {"ＡＢＣ１２３", "abc123"},
+		"katakana":              {"カタカナ", "かたかな"},
+		"half-width katakana":   {"ｶﾀｶﾅ", "かたかな"},
+		"dakuten":               {"ガギグ", "がぎぐ"},
+		"greek":                 {"ΣΟΦΌΣ", "σοφοσ"},
+		"cyrillic":              {"Ёлка", "елка"},
+		"hangul":                {"한국", "한국"},
+		"devanagari":            {"नमस्ते", "नमस्ते"},
+		"emoji":                 {"👨‍👩‍👧", "👨‍👩‍👧"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if folded := Fold(test.word); folded != test.folded {
+				t.Errorf("folded %q into %q, expected %q", test.word, folded, test.folded)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package words
 import (
 	"math/rand"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/kvnxiao/pictorio/model"
 )
@@ -19,7 +20,9 @@ var vowelsMap = map[rune]bool{
 }
 
 type GameWord struct {
-	word       string
+	word string
+	// folded is the word in the form that guesses are compared in
+	folded     string
 	wordLength []int
 	hints      []model.Hint
 }
@@ -36,6 +39,16 @@ func (w GameWord) Hints() []model.Hint {
 	return w.hints
 }
 
+// IsGuessedBy returns true if a guess is the word, or starts with the word, ignoring case, diacritics and spacing
+func (w GameWord) IsGuessedBy(guess string) bool {
+	return strings.HasPrefix(Fold(guess), w.folded)
+}
+
+// IsContainedIn returns true if a message contains the word, ignoring case, diacritics and spacing
+func (w GameWord) IsContainedIn(message string) bool {
+	return strings.Contains(Fold(message), w.folded)
+}
+
 func (w GameWord) Censored() string {
 	var censored []string
 	for _, length := range w.wordLength {
@@ -44,30 +57,29 @@ func (w GameWord) Censored() string {
 	return strings.Join(censored, " ")
 }
 
-func generateWordLength(word string) ([]int, []string) {
+// generateWordLength returns the number of characters in each word of a phrase, and the characters of each word
+func generateWordLength(word string) ([]int, [][]string) {
 	split := strings.Fields(word)
 
 	wordLengths := make([]int, len(split))
+	chars := make([][]string, len(split))
 	for i := 0; i < len(split); i++ {
-		wordLengths[i] = len(split[i])
+		chars[i] = Graphemes(split[i])
+		wordLengths[i] = len(chars[i])
 	}
-	return wordLengths, split
-}
-
-func isVowel(char rune) bool {
-	return vowelsMap[char]
+	return wordLengths, chars
 }
 
-func generateAllHints(splitWords []string) []model.Hint {
+func generateAllHints(splitWords [][]string, rng *rand.Rand) []model.Hint {
 	var hints []model.Hint
 
 	for i := 0; i < len(splitWords); i++ {
-		word := splitWords[i]
-		for j, c := range word {
-			char := c
+		for j, char := range splitWords[i] {
 			if !isVowel(char) {
+				r, _ := utf8.DecodeRuneInString(char)
 				hints = append(hints, model.Hint{
-					Char:      char,
+					Char:      r,
+					Text:      char,
 					WordIndex: i,
 					CharIndex: j,
 				})
@@ -75,20 +87,22 @@ func generateAllHints(splitWords []string) []model.Hint {
 		}
 	}
 
-	rand.Shuffle(len(hints), func(i, j int) {
+	rng.Shuffle(len(hints), func(i, j int) {
 		hints[i], hints[j] = hints[j], hints[i]
 	})
 
 	return hints
 }
 
-func NewGameWord(word string) GameWord {
+// NewGameWord processes a word for the game, using the given source of randomness to shuffle the order of its hints
+func NewGameWord(word string, rng *rand.Rand) GameWord {
 	processedWord := strings.ToLower(word)
 	wordLength, splitWords := generateWordLength(processedWord)
-	hints := generateAllHints(splitWords)
+	hints := generateAllHints(splitWords, rng)
 
 	return GameWord{
 		word:       processedWord,
+		folded:     Fold(processedWord),
 		wordLength: wordLength,
 		hints:      hints,
 	}
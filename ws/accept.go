@@ -5,9 +5,12 @@ package ws
 import (
 	"net/http"
 
+	"github.com/kvnxiao/pictorio/codec"
 	"nhooyr.io/websocket"
 )
 
 func Accept(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
-	return websocket.Accept(w, r, nil)
+	return websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols: codec.Subprotocols(),
+	})
 }
@@ -5,11 +5,13 @@ package ws
 import (
 	"net/http"
 
+	"github.com/kvnxiao/pictorio/codec"
 	"nhooyr.io/websocket"
 )
 
 func Accept(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	return websocket.Accept(w, r, &websocket.AcceptOptions{
 		OriginPatterns: []string{"localhost:8080"},
+		Subprotocols:   codec.Subprotocols(),
 	})
 }